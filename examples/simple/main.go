@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/damienfamed75/pine/tdraw"
 	"github.com/damienfamed75/pine/view"
+	"github.com/disintegration/gift"
 	"github.com/go-gl/mathgl/mgl64"
 
 	"github.com/oakmound/oak"
@@ -75,16 +77,23 @@ func NewHello() *HelloScene {
 	return &HelloScene{
 		modelPath:   filepath.Join("model", "dwarf.obj"),
 		texturePath: "dwarf_diffuse.png",
-		camera:      view.NewCamera(mgl64.Vec3{1, 0.75, 1}, mgl64.DegToRad(90), aspect),
+		// Look at the middle of the dwarf from in front of it and to the side.
+		camera: view.NewLookAtCamera(
+			mgl64.Vec3{1, 0.75, 1}, mgl64.Vec3{0, 0.5, 0}, mgl64.DegToRad(90), aspect,
+		),
 	}
 }
 
 // Start is the initializer stage right when the scene is loaded into oak.
 func (h *HelloScene) Start(string, interface{}) {
 	// Load an obj file using the new object loader in view package.
-	// Also load in the texture and store the necessary values to render it on
-	// the screen.
-	r, err := view.LoadObj(
+	// Also load in the texture, softening it with a mean blur, and store the
+	// necessary values to render it on the screen.
+	loader := &view.ObjLoader{
+		// ksize must be an odd number.
+		TextureFilters: []gift.Filter{gift.Mean(5, true)},
+	}
+	r, err := loader.Load(
 		h.modelPath,
 		h.texturePath,
 		oak.ScreenWidth,
@@ -97,6 +106,9 @@ func (h *HelloScene) Start(string, interface{}) {
 		return
 	}
 
+	// The dwarf is a closed model, so its back faces never need drawing.
+	r.SetCullMode(tdraw.CullBack)
+
 	render.GlobalDrawStack.Push(render.NewLogicFPS())
 	// Set the renderable object.
 	h.r = r
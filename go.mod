@@ -9,7 +9,7 @@ require (
 	github.com/BurntSushi/xgbutil v0.0.0-20190907113008-ad855c713046 // indirect
 	github.com/akavel/polyclip-go v0.0.0-20160111220610-2cfdb71461bd // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/disintegration/gift v1.2.1 // indirect
+	github.com/disintegration/gift v1.2.1
 	github.com/fogleman/fauxgl v0.0.0-20191107030710-cdc7b750f217
 	github.com/fogleman/simplify v0.0.0-20170216171241-d32f302d5046 // indirect
 	github.com/go-gl/mathgl v0.0.0-20190713194549-592312d8590a
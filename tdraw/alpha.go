@@ -0,0 +1,79 @@
+package tdraw
+
+import (
+	"image/color"
+	"sort"
+)
+
+// AlphaMode decides what the alpha of the pixels a triangle draws is used
+// for. The colors returned by fragment shaders have their red, green and blue
+// already multiplied by their alpha, the same as color.RGBA.
+type AlphaMode int
+
+const (
+	// AlphaOpaque draws every pixel over whatever is behind it, leaving the
+	// alpha as it is.
+	AlphaOpaque AlphaMode = iota
+	// AlphaTest skips pixels whose alpha is below the cutoff and draws the
+	// rest like AlphaOpaque, which cuts the shape of leaves and fences out
+	// of their textures.
+	AlphaTest
+	// AlphaBlend mixes pixels with whatever is behind them by their alpha,
+	// for glass and fading effects. Blended pixels don't write their depth,
+	// so they must be drawn after everything that's opaque and sorted from
+	// back to front, refer to SortBackToFront.
+	AlphaBlend
+)
+
+// DefaultAlphaCutoff is the alpha from 0 to 1 below which AlphaTest usually
+// skips pixels.
+const DefaultAlphaCutoff = 0.5
+
+// String returns the name of the alpha mode.
+func (a AlphaMode) String() string {
+	switch a {
+	case AlphaOpaque:
+		return "opaque"
+	case AlphaTest:
+		return "test"
+	case AlphaBlend:
+		return "blend"
+	default:
+		return "unknown"
+	}
+}
+
+// blend returns the color src drawn over dst, which both have their alpha
+// already multiplied in.
+func blend(src, dst color.RGBA) color.RGBA {
+	a := 0xFF - uint32(src.A)
+	return color.RGBA{
+		R: clamp8(uint32(src.R) + uint32(dst.R)*a/0xFF),
+		G: clamp8(uint32(src.G) + uint32(dst.G)*a/0xFF),
+		B: clamp8(uint32(src.B) + uint32(dst.B)*a/0xFF),
+		A: clamp8(uint32(src.A) + uint32(dst.A)*a/0xFF),
+	}
+}
+
+// clamp8 returns v as a uint8, clamped to 255.
+func clamp8(v uint32) uint8 {
+	if v > 0xFF {
+		return 0xFF
+	}
+	return uint8(v)
+}
+
+// SortBackToFront sorts primitives by their distance from the camera, with
+// the furthest first, so blended primitives are mixed with the ones behind
+// them. Primitives at the same distance keep their order.
+func SortBackToFront(prims []Primitive) {
+	sort.SliceStable(prims, func(i, j int) bool {
+		return prims[i].depth() < prims[j].depth()
+	})
+}
+
+// depth returns the depth of the primitive's center, which is greater for
+// primitives that are closer to the camera.
+func (p *Primitive) depth() float64 {
+	return (p.vew.A.Z() + p.vew.B.Z() + p.vew.C.Z()) / 3
+}
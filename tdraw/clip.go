@@ -0,0 +1,164 @@
+package tdraw
+
+import "github.com/go-gl/mathgl/mgl64"
+
+// Vertex is a vertex of a triangle in clip space, which is the space after
+// the projection matrix has been applied but before the perspective divide,
+// along with the attributes that are interpolated across the triangle.
+type Vertex struct {
+	Position mgl64.Vec4
+	// Eye is the position of the vertex in eye space, which lights are
+	// measured from.
+	Eye    mgl64.Vec3
+	Normal mgl64.Vec3
+	UV     mgl64.Vec3
+	Color  mgl64.Vec3
+	// Diffuse and Specular are the light that reaches the vertex, for
+	// shaders that light their vertices rather than every pixel, such as
+	// with GouraudShading.
+	Diffuse  mgl64.Vec3
+	Specular mgl64.Vec3
+	// Varyings are extra values for the fragment stage, refer to Shader.
+	Varyings Varyings
+}
+
+// Lerp linearly interpolates every attribute of the vertex towards o,
+// where t is 0 at v and 1 at o.
+func (v Vertex) Lerp(o Vertex, t float64) Vertex {
+	l := Vertex{
+		Position: v.Position.Add(o.Position.Sub(v.Position).Mul(t)),
+		Eye:      v.Eye.Add(o.Eye.Sub(v.Eye).Mul(t)),
+		Normal:   v.Normal.Add(o.Normal.Sub(v.Normal).Mul(t)),
+		UV:       v.UV.Add(o.UV.Sub(v.UV).Mul(t)),
+		Color:    v.Color.Add(o.Color.Sub(v.Color).Mul(t)),
+		Diffuse:  v.Diffuse.Add(o.Diffuse.Sub(v.Diffuse).Mul(t)),
+		Specular: v.Specular.Add(o.Specular.Sub(v.Specular).Mul(t)),
+	}
+	for i := range l.Varyings {
+		l.Varyings[i] = v.Varyings[i] + (o.Varyings[i]-v.Varyings[i])*t
+	}
+
+	return l
+}
+
+// The planes of the view frustum that polygons are clipped against. A
+// position in clip space is inside of a plane when the dot product of the
+// plane and the position is positive, so the near plane keeps -w <= z and
+// the far plane keeps z <= w.
+var (
+	nearPlane = mgl64.Vec4{0, 0, 1, 1}
+	farPlane  = mgl64.Vec4{0, 0, -1, 1}
+)
+
+// ClipPolygon clips a convex polygon against the near and far planes of the
+// view frustum using the Sutherland–Hodgman algorithm. Vertices that are
+// created along the planes have their attributes interpolated. The returned
+// polygon is empty when the polygon is entirely outside of the frustum.
+//
+// The sides of the frustum aren't clipped against, since TDraw already
+// scissors triangles to the edges of the buffer.
+func ClipPolygon(polygon []Vertex) []Vertex {
+	for _, plane := range []mgl64.Vec4{nearPlane, farPlane} {
+		polygon = clipPlane(polygon, plane)
+		if len(polygon) == 0 {
+			return nil
+		}
+	}
+
+	return polygon
+}
+
+// clipPlane clips a polygon against a single plane, keeping the part of the
+// polygon that is inside of it.
+func clipPlane(polygon []Vertex, plane mgl64.Vec4) []Vertex {
+	clipped := make([]Vertex, 0, len(polygon)+1)
+
+	for i, cur := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		dCur := plane.Dot(cur.Position)
+		dNext := plane.Dot(next.Position)
+
+		if dCur >= 0 {
+			clipped = append(clipped, cur)
+		}
+		// The edge crosses the plane, so a vertex is added where it crosses.
+		if (dCur >= 0) != (dNext >= 0) {
+			clipped = append(clipped, cur.Lerp(next, dCur/(dCur-dNext)))
+		}
+	}
+
+	return clipped
+}
+
+// DrawTriangle clips a triangle in clip space against the view frustum,
+// projects what is left of it onto the frame buffer and draws it, coloring
+// its pixels with the shader. face is handed to the shader in each Fragment.
+// The state decides whether the triangle is culled, how its attributes are
+// blended and what the alpha of its pixels is used for.
+func DrawTriangle(fb *FrameBuffer, face int, tri [3]Vertex, shader FragmentShader, state DrawState) {
+	bounds := fb.Bounds()
+	for _, p := range AppendTriangle(nil, bounds.Dx(), bounds.Dy(), face, tri, shader, state) {
+		p.Draw(fb, bounds)
+	}
+}
+
+// AppendTriangle clips a triangle in clip space against the view frustum,
+// projects what is left of it onto a buffer of the given width and height,
+// and appends the primitives it was split into to prims. Nothing is appended
+// when the triangle is culled or outside of the frustum. The primitives are
+// colored the same way as DrawTriangle colors them.
+func AppendTriangle(prims []Primitive, w, h, face int, tri [3]Vertex, shader FragmentShader, state DrawState) []Primitive {
+	polygon := ClipPolygon(tri[:])
+	if len(polygon) < 3 {
+		return prims
+	}
+
+	screen := make([]mgl64.Vec3, len(polygon))
+	for i, v := range polygon {
+		screen[i] = viewport(v.Position, float64(w), float64(h))
+	}
+
+	// The winding is checked after clipping, since vertices behind the
+	// camera flip when they're projected.
+	if state.Cull.culls(screen) {
+		return prims
+	}
+
+	// Clipping always leaves a convex polygon, which is split into a fan of
+	// triangles around its first vertex.
+	for i := 1; i+1 < len(polygon); i++ {
+		a, b, c := polygon[0], polygon[i], polygon[i+1]
+
+		prims = append(prims, Primitive{
+			vew:      Triangle{screen[0], screen[i], screen[i+1]},
+			eye:      Triangle{a.Eye, b.Eye, c.Eye},
+			nrm:      Triangle{a.Normal, b.Normal, c.Normal},
+			tex:      Triangle{a.UV, b.UV, c.UV},
+			col:      Triangle{a.Color, b.Color, c.Color},
+			dif:      Triangle{a.Diffuse, b.Diffuse, c.Diffuse},
+			spc:      Triangle{a.Specular, b.Specular, c.Specular},
+			varyings: [3]Varyings{a.Varyings, b.Varyings, c.Varyings},
+			weights:  state.Interpolation.weights(a, b, c),
+			face:     face,
+			shader:   shader,
+			alpha:    state.Alpha,
+			cutoff:   uint8(mgl64.Clamp(state.AlphaCutoff, 0, 1) * 0xFF),
+		})
+	}
+
+	return prims
+}
+
+// viewport divides a position in clip space by w and maps it onto a buffer
+// of the given width and height. The y axis is flipped since images start at
+// the top, and the depth is flipped so that closer pixels have a larger
+// depth, which is what TDraw's depth test expects.
+func viewport(clip mgl64.Vec4, w, h float64) mgl64.Vec3 {
+	ndc := clip.Mul(1 / clip.W())
+
+	return mgl64.Vec3{
+		w * (ndc.X() + 1) / 2,
+		h * (1 - ndc.Y()) / 2,
+		(1 - ndc.Z()) / 2,
+	}
+}
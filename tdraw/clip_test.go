@@ -0,0 +1,85 @@
+package tdraw
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// clipVertex returns a vertex at the position in clip space, with a w of 1,
+// whose texture coordinate and color are both its position so that the
+// interpolated attributes of new vertices can be checked against where they
+// were created.
+func clipVertex(x, y, z float64) Vertex {
+	pos := mgl64.Vec3{x, y, z}
+	return Vertex{Position: pos.Vec4(1), UV: pos, Color: pos}
+}
+
+func TestClipPolygon(t *testing.T) {
+	var (
+		a = clipVertex(0, 0, 0)
+		b = clipVertex(1, 0, 0)
+		c = clipVertex(0, 1, 0)
+	)
+	// behind moves a vertex 3 behind the camera, past the near plane at a z
+	// of -1.
+	behind := func(v Vertex) Vertex { return clipVertex(v.Position.X(), v.Position.Y(), -3) }
+
+	tests := []struct {
+		name string
+		tri  [3]Vertex
+		want []Vertex
+	}{
+		{
+			name: "inside",
+			tri:  [3]Vertex{a, b, c},
+			want: []Vertex{a, b, c},
+		},
+		{
+			name: "behind the camera",
+			tri:  [3]Vertex{behind(a), behind(b), behind(c)},
+			want: nil,
+		},
+		{
+			name: "past the far plane",
+			tri:  [3]Vertex{clipVertex(0, 0, 2), clipVertex(1, 0, 2), clipVertex(0, 1, 2)},
+			want: nil,
+		},
+		{
+			// The vertex behind the near plane is cut off, leaving a quad
+			// with two new vertices a third of the way along its edges.
+			name: "one vertex behind",
+			tri:  [3]Vertex{a, b, behind(c)},
+			want: []Vertex{a, b, clipVertex(2.0/3, 1.0/3, -1), clipVertex(0, 1.0/3, -1)},
+		},
+		{
+			name: "two vertices behind",
+			tri:  [3]Vertex{a, behind(b), behind(c)},
+			want: []Vertex{a, clipVertex(1.0/3, 0, -1), clipVertex(0, 1.0/3, -1)},
+		},
+		{
+			name: "one vertex past the far plane",
+			tri:  [3]Vertex{a, b, clipVertex(0, 1, 3)},
+			want: []Vertex{a, b, clipVertex(2.0/3, 1.0/3, 1), clipVertex(0, 1.0/3, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClipPolygon(tt.tri[:])
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d vertices, want %d: %v", len(got), len(tt.want), got)
+			}
+
+			for i, v := range got {
+				want := tt.want[i]
+				if !v.Position.ApproxEqual(want.Position) {
+					t.Errorf("vertex %d is at %v, want %v", i, v.Position, want.Position)
+				}
+				if !v.UV.ApproxEqual(want.UV) || !v.Color.ApproxEqual(want.Color) {
+					t.Errorf("vertex %d has texture coordinate %v and color %v, want %v", i, v.UV, v.Color, want.UV)
+				}
+			}
+		})
+	}
+}
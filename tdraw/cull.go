@@ -0,0 +1,54 @@
+package tdraw
+
+import "github.com/go-gl/mathgl/mgl64"
+
+// CullMode decides which triangles are skipped based on which way they're
+// facing once projected onto the screen. Triangles whose vertices go
+// counter-clockwise are facing the camera.
+type CullMode int
+
+const (
+	// CullNone draws every triangle, which is what double-sided models
+	// such as foliage need.
+	CullNone CullMode = iota
+	// CullBack skips triangles that are facing away from the camera.
+	CullBack
+	// CullFront skips triangles that are facing the camera.
+	CullFront
+)
+
+// String returns the name of the cull mode.
+func (c CullMode) String() string {
+	switch c {
+	case CullNone:
+		return "none"
+	case CullBack:
+		return "back"
+	case CullFront:
+		return "front"
+	default:
+		return "unknown"
+	}
+}
+
+// culls returns whether a polygon on the screen is skipped by the cull mode.
+// The polygon's vertices are in buffer coordinates, where y points down.
+func (c CullMode) culls(polygon []mgl64.Vec3) bool {
+	if c == CullNone {
+		return false
+	}
+
+	// Twice the signed area of the polygon. Since y points down on the
+	// buffer, polygons that are counter-clockwise in normalized device
+	// coordinates have a negative area.
+	var area float64
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		area += a.X()*b.Y() - b.X()*a.Y()
+	}
+
+	if c == CullBack {
+		return area >= 0
+	}
+	return area <= 0
+}
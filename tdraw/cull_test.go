@@ -0,0 +1,66 @@
+package tdraw
+
+import (
+	"image/color"
+	"testing"
+)
+
+// solidShader colors every pixel with the same color.
+type solidShader struct {
+	color color.RGBA
+}
+
+func (s *solidShader) Fragment(f *Fragment) (color.RGBA, bool) {
+	return s.color, true
+}
+
+// drawnPixels returns how many pixels of the frame buffer have been drawn.
+func drawnPixels(fb *FrameBuffer) int {
+	var n int
+	for _, d := range fb.Depth {
+		if d != FarDepth {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCullMode(t *testing.T) {
+	var (
+		// The triangle's vertices go counter-clockwise in clip space, where
+		// y points up, so it's facing the camera.
+		ccw = [3]Vertex{clipVertex(-0.5, -0.5, 0), clipVertex(0.5, -0.5, 0), clipVertex(0, 0.5, 0)}
+		cw  = [3]Vertex{ccw[0], ccw[2], ccw[1]}
+	)
+
+	tests := []struct {
+		cull            CullMode
+		wantCCW, wantCW bool
+	}{
+		{cull: CullNone, wantCCW: true, wantCW: true},
+		{cull: CullBack, wantCCW: true, wantCW: false},
+		{cull: CullFront, wantCCW: false, wantCW: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cull.String(), func(t *testing.T) {
+			shader := &solidShader{color: color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}}
+
+			for _, tri := range []struct {
+				name      string
+				tri       [3]Vertex
+				wantDrawn bool
+			}{
+				{"counter-clockwise", ccw, tt.wantCCW},
+				{"clockwise", cw, tt.wantCW},
+			} {
+				fb := NewFrameBuffer(16, 16)
+				DrawTriangle(fb, 0, tri.tri, shader, DrawState{Cull: tt.cull})
+
+				if drawn := drawnPixels(fb) > 0; drawn != tri.wantDrawn {
+					t.Errorf("%s triangle drawn: %t, want %t", tri.name, drawn, tri.wantDrawn)
+				}
+			}
+		})
+	}
+}
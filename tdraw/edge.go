@@ -0,0 +1,48 @@
+package tdraw
+
+import "github.com/go-gl/mathgl/mgl64"
+
+// edge is the edge function of one side of a triangle on the screen, which is
+// positive on the inside of the triangle, zero along the edge and negative on
+// the outside. Since it's linear it's stepped incrementally between pixels
+// instead of being solved for each of them.
+type edge struct {
+	// w is the value of the edge function at the current pixel.
+	w float64
+	// stepX and stepY are how much w changes when moving one pixel along
+	// the x and y axis.
+	stepX, stepY float64
+	// topLeft is whether pixels exactly on the edge belong to the triangle.
+	topLeft bool
+}
+
+// newEdge returns the edge going from p to q, evaluated at the pixel x, y.
+// sign is 1 for triangles that are clockwise on the screen and -1 for ones
+// that are counter-clockwise, so the inside of the triangle is always
+// positive.
+func newEdge(p, q mgl64.Vec3, x, y, sign float64) edge {
+	e := edge{
+		stepX: -(q.Y() - p.Y()) * sign,
+		stepY: (q.X() - p.X()) * sign,
+	}
+	e.w = e.stepY*(y-p.Y()) + e.stepX*(x-p.X())
+
+	// The top-left fill rule: pixels exactly on an edge are only drawn when
+	// it's a left edge, where the inside is to the right of it, or a top
+	// edge, where the inside is below it. Two triangles that share an edge
+	// never draw the same pixel.
+	e.topLeft = e.stepX > 0 || (e.stepX == 0 && e.stepY > 0)
+
+	return e
+}
+
+// inside returns whether the current pixel is inside of the edge.
+func (e *edge) inside() bool {
+	return e.w > 0 || (e.w == 0 && e.topLeft)
+}
+
+// at returns the edge evaluated n pixels further along the y axis.
+func (e edge) at(n int) edge {
+	e.w += e.stepY * float64(n)
+	return e
+}
@@ -0,0 +1,94 @@
+package tdraw
+
+import (
+	"image"
+	"math"
+)
+
+// FarDepth is the depth that a cleared frame buffer is filled with. Every
+// pixel drawn is closer than it.
+const FarDepth = -math.MaxFloat64
+
+// FrameBuffer holds the color and depth of every pixel being drawn to. Frame
+// buffers are meant to be kept around and cleared each frame rather than
+// allocated again, and one frame buffer can be shared by any number of
+// models so that they're drawn together with the same depth.
+type FrameBuffer struct {
+	// Color is the image that is drawn to.
+	Color *image.RGBA
+	// Depth holds the depth of every pixel, row by row, so the depth of the
+	// pixel at x, y is Depth[y*w+x]. Larger depths are closer to the camera.
+	Depth []float64
+
+	w, h int
+}
+
+// NewFrameBuffer returns a cleared frame buffer of the given width and
+// height.
+func NewFrameBuffer(w, h int) *FrameBuffer {
+	f := &FrameBuffer{}
+	f.Resize(w, h)
+	f.Clear()
+
+	return f
+}
+
+// Bounds returns the bounds of the frame buffer, which always start at 0, 0.
+func (f *FrameBuffer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, f.w, f.h)
+}
+
+// Resize changes the size of the frame buffer. Memory is only allocated when
+// the frame buffer grows larger than it has ever been, so switching between
+// sizes is cheap. The contents are undefined after resizing, so the frame
+// buffer should be cleared before it's drawn to.
+//
+// Color is replaced when the size changes, so references to the old image
+// should be refreshed.
+func (f *FrameBuffer) Resize(w, h int) {
+	if w < 0 {
+		w = 0
+	}
+	if h < 0 {
+		h = 0
+	}
+	if f.Color != nil && w == f.w && h == f.h {
+		return
+	}
+
+	var pix []uint8
+	if f.Color != nil {
+		pix = f.Color.Pix
+	}
+	if cap(pix) < 4*w*h {
+		pix = make([]uint8, 4*w*h)
+	}
+	if cap(f.Depth) < w*h {
+		f.Depth = make([]float64, w*h)
+	}
+
+	f.Color = &image.RGBA{
+		Pix:    pix[:4*w*h],
+		Stride: 4 * w,
+		Rect:   image.Rect(0, 0, w, h),
+	}
+	f.Depth = f.Depth[:w*h]
+	f.w, f.h = w, h
+}
+
+// Clear makes every pixel transparent and as far back as possible, without
+// allocating.
+func (f *FrameBuffer) Clear() {
+	pix := f.Color.Pix
+	for i := range pix {
+		pix[i] = 0
+	}
+	for i := range f.Depth {
+		f.Depth[i] = FarDepth
+	}
+}
+
+// depthIndex returns the index of the pixel at x, y in Depth.
+func (f *FrameBuffer) depthIndex(x, y int) int {
+	return y*f.w + x
+}
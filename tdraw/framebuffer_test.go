@@ -0,0 +1,113 @@
+package tdraw
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// checkCleared fails the test unless every pixel of the frame buffer is
+// transparent and at FarDepth.
+func checkCleared(t *testing.T, fb *FrameBuffer) {
+	t.Helper()
+
+	for i, p := range fb.Color.Pix {
+		if p != 0 {
+			t.Fatalf("got %d in the color at %d, want 0", p, i)
+		}
+	}
+	for i, d := range fb.Depth {
+		if d != FarDepth {
+			t.Fatalf("got depth %v at %d, want FarDepth", d, i)
+		}
+	}
+}
+
+func TestFrameBufferResize(t *testing.T) {
+	fb := NewFrameBuffer(4, 3)
+	checkCleared(t, fb)
+
+	for _, size := range []image.Point{
+		{8, 6},
+		{2, 2},
+		{0, 5},
+		{6, 8},
+	} {
+		// Scribble over the frame buffer so that clearing it can be checked.
+		for i := range fb.Color.Pix {
+			fb.Color.Pix[i] = 0xFF
+		}
+		for i := range fb.Depth {
+			fb.Depth[i] = 1
+		}
+
+		fb.Resize(size.X, size.Y)
+		fb.Clear()
+
+		if got, want := fb.Bounds(), (image.Rectangle{Max: size}); got != want {
+			t.Errorf("got bounds %v, want %v", got, want)
+		}
+		if got := fb.Color.Bounds(); got != fb.Bounds() {
+			t.Errorf("got color bounds %v, want %v", got, fb.Bounds())
+		}
+		if got, want := len(fb.Depth), size.X*size.Y; got != want {
+			t.Errorf("got %d depths, want %d", got, want)
+		}
+		checkCleared(t, fb)
+	}
+
+	// The frame buffer has never been larger than 48 pixels, so shrinking
+	// and growing back kept the memory from growing to 8 by 6.
+	if cap(fb.Depth) != 48 || cap(fb.Color.Pix) != 4*48 {
+		t.Errorf("got room for %d depths and %d colors, want 48", cap(fb.Depth), cap(fb.Color.Pix)/4)
+	}
+
+	// Negative sizes are treated as empty.
+	fb.Resize(-1, 4)
+	if !fb.Bounds().Empty() || len(fb.Depth) != 0 {
+		t.Errorf("got bounds %v and %d depths, want an empty frame buffer", fb.Bounds(), len(fb.Depth))
+	}
+}
+
+func TestFrameBufferDepthIndex(t *testing.T) {
+	const w, h = 5, 3
+	fb := NewFrameBuffer(w, h)
+
+	// Every pixel has its own depth, row by row.
+	seen := make(map[int]bool)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := fb.depthIndex(x, y)
+			if i != y*w+x {
+				t.Errorf("got index %d for %d, %d, want %d", i, x, y, y*w+x)
+			}
+			seen[i] = true
+		}
+	}
+	if len(seen) != len(fb.Depth) {
+		t.Errorf("got %d indices, want one for each of the %d depths", len(seen), len(fb.Depth))
+	}
+
+	// Drawing a triangle in a corner of a wider frame buffer sets the depth
+	// of exactly the pixels it colors.
+	fb.Resize(16, 8)
+	fb.Clear()
+	tri := [3]Vertex{clipVertex(0, 0, 0), clipVertex(1, 0, 0), clipVertex(1, 1, 0)}
+	DrawTriangle(fb, 0, tri, &solidShader{color: color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}}, DrawState{})
+
+	var drawn int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			colored := fb.Color.RGBAAt(x, y).A != 0
+			if deep := fb.Depth[y*16+x] != FarDepth; colored != deep {
+				t.Errorf("pixel %d, %d is colored: %t, but has a depth: %t", x, y, colored, deep)
+			}
+			if colored {
+				drawn++
+			}
+		}
+	}
+	if drawn == 0 {
+		t.Error("nothing was drawn")
+	}
+}
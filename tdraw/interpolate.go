@@ -0,0 +1,52 @@
+package tdraw
+
+import "github.com/go-gl/mathgl/mgl64"
+
+// Interpolation decides how the attributes of a triangle's vertices, such as
+// the texture coordinates, normals and colors, are blended across it.
+type Interpolation int
+
+const (
+	// PerspectiveCorrect interpolates attributes in eye space, so textures
+	// don't warp on triangles that are seen at an angle.
+	PerspectiveCorrect Interpolation = iota
+	// Affine interpolates attributes linearly across the screen, which is
+	// cheaper but warps textures the way older consoles did.
+	Affine
+)
+
+// String returns the name of the interpolation.
+func (i Interpolation) String() string {
+	switch i {
+	case PerspectiveCorrect:
+		return "perspective correct"
+	case Affine:
+		return "affine"
+	default:
+		return "unknown"
+	}
+}
+
+// weights returns the weight of each vertex of a triangle in clip space when
+// its attributes are interpolated, which is 1/w for perspective correct
+// interpolation. The weights are in the order of the triangle's vertices.
+func (i Interpolation) weights(a, b, c Vertex) mgl64.Vec3 {
+	if i == Affine {
+		return mgl64.Vec3{1, 1, 1}
+	}
+
+	return mgl64.Vec3{1 / a.Position.W(), 1 / b.Position.W(), 1 / c.Position.W()}
+}
+
+// correct weighs the screen space barycentric coordinate bc, which is in the
+// order returned by BaryCenter, by the weights of the triangle's vertices,
+// and normalizes the result so the weights add up to 1 again.
+func correct(bc, weights mgl64.Vec3) mgl64.Vec3 {
+	pc := mgl64.Vec3{
+		bc.X() * weights.Y(),
+		bc.Y() * weights.Z(),
+		bc.Z() * weights.X(),
+	}
+
+	return pc.Mul(1 / (pc.X() + pc.Y() + pc.Z()))
+}
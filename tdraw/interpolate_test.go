@@ -0,0 +1,65 @@
+package tdraw
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// uvShader records the texture coordinate of every pixel it shades.
+type uvShader struct {
+	uvs map[image.Point]mgl64.Vec3
+}
+
+func (s *uvShader) Fragment(f *Fragment) (color.RGBA, bool) {
+	s.uvs[image.Pt(f.X, f.Y)] = f.UV
+	return color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, true
+}
+
+func TestInterpolation(t *testing.T) {
+	const size = 64
+
+	// The second vertex is three times as far from the camera as the others,
+	// so it's given a w of 3. The vertices are at the same places on the
+	// screen as (-0.5, -0.5), (0.5, -0.5) and (0, 0.5).
+	tri := [3]Vertex{
+		{Position: mgl64.Vec4{-0.5, -0.5, 0, 1}, UV: mgl64.Vec3{0, 0, 0}},
+		{Position: mgl64.Vec4{1.5, -1.5, 0, 3}, UV: mgl64.Vec3{1, 0, 0}},
+		{Position: mgl64.Vec4{0, 0.5, 0, 1}, UV: mgl64.Vec3{0, 1, 0}},
+	}
+	// The center of the triangle on the screen is at (0, -1/6), which is the
+	// pixel below.
+	center := image.Pt(size/2, size*7/12)
+
+	tests := []struct {
+		interp Interpolation
+		want   mgl64.Vec3
+	}{
+		// Affine interpolation takes the average of the texture
+		// coordinates at the center of the screen.
+		{Affine, mgl64.Vec3{1.0 / 3, 1.0 / 3, 0}},
+		// Perspective correct interpolation weighs each vertex by 1/w, so
+		// the far vertex counts for a third as much as the others.
+		{PerspectiveCorrect, mgl64.Vec3{1.0 / 7, 3.0 / 7, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interp.String(), func(t *testing.T) {
+			fb := NewFrameBuffer(size, size)
+			shader := &uvShader{uvs: make(map[image.Point]mgl64.Vec3)}
+			DrawTriangle(fb, 0, tri, shader, DrawState{Interpolation: tt.interp})
+
+			uv, ok := shader.uvs[center]
+			if !ok {
+				t.Fatalf("the pixel at %v wasn't drawn", center)
+			}
+			// The pixel isn't exactly at the center, so the texture
+			// coordinate is a little off.
+			if !uv.ApproxEqualThreshold(tt.want, 0.03) {
+				t.Errorf("got texture coordinate %v at the center, want %v", uv, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package tdraw
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// LightKind is the kind of light source a Light is.
+type LightKind int
+
+const (
+	// AmbientLight lights every surface the same, no matter where it is or
+	// which way it's facing.
+	AmbientLight LightKind = iota
+	// DirectionalLight is a light that is infinitely far away, such as the
+	// sun, so its light travels in the same direction everywhere.
+	DirectionalLight
+	// PointLight shines in every direction from its position, such as a
+	// torch, and gets dimmer further away.
+	PointLight
+	// SpotLight shines in a cone from its position, such as a flashlight,
+	// and gets dimmer further away and towards the edge of its cone.
+	SpotLight
+)
+
+// String returns the name of the light kind.
+func (k LightKind) String() string {
+	switch k {
+	case AmbientLight:
+		return "ambient"
+	case DirectionalLight:
+		return "directional"
+	case PointLight:
+		return "point"
+	case SpotLight:
+		return "spot"
+	default:
+		return "unknown"
+	}
+}
+
+// Light is a source of light. Lights are in the same space as the vertices
+// they light, which is eye space by the time triangles are drawn.
+type Light struct {
+	Kind LightKind
+	// Color is the color of the light, with components from 0 to 1, which
+	// is multiplied by the intensity.
+	Color     mgl64.Vec3
+	Intensity float64
+
+	// Position is where point and spot lights shine from.
+	Position mgl64.Vec3
+	// Direction is the direction that directional and spot lights shine
+	// towards.
+	Direction mgl64.Vec3
+
+	// Attenuation holds the constant, linear and quadratic factors of how
+	// point and spot lights get dimmer with distance. The light is divided
+	// by constant + linear*d + quadratic*d*d at a distance of d.
+	Attenuation mgl64.Vec3
+	// InnerCone and OuterCone are the angles in radians between a spot
+	// light's direction and the edge of its cone. The light is at its
+	// brightest inside of the inner cone and fades out towards the outer
+	// cone.
+	InnerCone, OuterCone float64
+}
+
+// DefaultAttenuation is the attenuation of lights made by NewPointLight and
+// NewSpotLight, which fades them out over roughly 10 units.
+var DefaultAttenuation = mgl64.Vec3{1, 0.35, 0.44}
+
+// NewAmbientLight returns an ambient light of the given color and intensity.
+func NewAmbientLight(color mgl64.Vec3, intensity float64) Light {
+	return Light{Kind: AmbientLight, Color: color, Intensity: intensity}
+}
+
+// NewDirectionalLight returns a directional light that shines towards
+// direction.
+func NewDirectionalLight(direction, color mgl64.Vec3, intensity float64) Light {
+	return Light{
+		Kind:      DirectionalLight,
+		Color:     color,
+		Intensity: intensity,
+		Direction: direction.Normalize(),
+	}
+}
+
+// NewPointLight returns a point light at position with the default
+// attenuation.
+func NewPointLight(position, color mgl64.Vec3, intensity float64) Light {
+	return Light{
+		Kind:        PointLight,
+		Color:       color,
+		Intensity:   intensity,
+		Position:    position,
+		Attenuation: DefaultAttenuation,
+	}
+}
+
+// NewSpotLight returns a spot light at position shining towards direction
+// with the default attenuation. inner and outer are the angles of its cone
+// in radians, refer to Light.
+func NewSpotLight(position, direction mgl64.Vec3, inner, outer float64, color mgl64.Vec3, intensity float64) Light {
+	return Light{
+		Kind:        SpotLight,
+		Color:       color,
+		Intensity:   intensity,
+		Position:    position,
+		Direction:   direction.Normalize(),
+		Attenuation: DefaultAttenuation,
+		InnerCone:   inner,
+		OuterCone:   outer,
+	}
+}
+
+// Transform returns the light moved by the matrix, which is how lights in
+// world space are moved into eye space.
+func (l Light) Transform(m mgl64.Mat4) Light {
+	l.Position = m.Mul4x1(l.Position.Vec4(1)).Vec3()
+	if l.Direction.Len() > 0 {
+		l.Direction = m.Mul4x1(l.Direction.Vec4(0)).Vec3().Normalize()
+	}
+
+	return l
+}
+
+// Incident returns the direction from a point at position towards the light,
+// and the color and brightness of the light that reaches the point. Ambient
+// lights have no direction, since they come from everywhere.
+func (l Light) Incident(position mgl64.Vec3) (toLight, radiance mgl64.Vec3) {
+	radiance = l.Color.Mul(l.Intensity)
+
+	switch l.Kind {
+	case DirectionalLight:
+		return l.Direction.Mul(-1), radiance
+	case PointLight, SpotLight:
+		toLight = l.Position.Sub(position)
+		d := toLight.Len()
+		if d > 0 {
+			toLight = toLight.Mul(1 / d)
+		}
+
+		att := l.Attenuation.X() + l.Attenuation.Y()*d + l.Attenuation.Z()*d*d
+		if att > 0 {
+			radiance = radiance.Mul(1 / att)
+		}
+
+		if l.Kind == SpotLight {
+			// The cosine of the angle between the spot light's direction
+			// and the point, faded between the edges of the two cones.
+			cos := toLight.Mul(-1).Dot(l.Direction)
+			radiance = radiance.Mul(smoothstep(math.Cos(l.OuterCone), math.Cos(l.InnerCone), cos))
+		}
+
+		return toLight, radiance
+	default:
+		return mgl64.Vec3{}, radiance
+	}
+}
+
+// Illuminate returns the diffuse light that reaches a point at position with
+// the given normal from all of the lights.
+func Illuminate(lights []Light, position, normal mgl64.Vec3) mgl64.Vec3 {
+	var total mgl64.Vec3
+	for _, l := range lights {
+		toLight, radiance := l.Incident(position)
+		if l.Kind == AmbientLight {
+			total = total.Add(radiance)
+			continue
+		}
+
+		if d := normal.Dot(toLight); d > 0 {
+			total = total.Add(radiance.Mul(d))
+		}
+	}
+
+	return total
+}
+
+// smoothstep returns 0 when x is below edge0, 1 when x is above edge1, and
+// smoothly goes between them in between.
+func smoothstep(edge0, edge1, x float64) float64 {
+	if edge0 == edge1 {
+		if x < edge0 {
+			return 0
+		}
+		return 1
+	}
+
+	t := mgl64.Clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,194 @@
+package tdraw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestLightIncident(t *testing.T) {
+	white := mgl64.Vec3{1, 1, 1}
+
+	point := NewPointLight(mgl64.Vec3{0, 2, 0}, white, 1)
+	point.Attenuation = mgl64.Vec3{1, 0.5, 0.25}
+
+	// The spot light shines straight down from 2 above the origin, with the
+	// edges of its cones at 45 and 60 degrees.
+	spot := NewSpotLight(mgl64.Vec3{0, 2, 0}, mgl64.Vec3{0, -1, 0}, math.Pi/4, math.Pi/3, white, 1)
+	spot.Attenuation = mgl64.Vec3{1, 0, 0}
+
+	tests := []struct {
+		name     string
+		light    Light
+		position mgl64.Vec3
+		// wantToLight is the direction towards the light, and wantRadiance
+		// the brightness that reaches the position in every channel.
+		wantToLight  mgl64.Vec3
+		wantRadiance float64
+	}{
+		{
+			name:         "ambient",
+			light:        NewAmbientLight(white, 0.25),
+			position:     mgl64.Vec3{5, 5, 5},
+			wantToLight:  mgl64.Vec3{},
+			wantRadiance: 0.25,
+		},
+		{
+			name:         "directional",
+			light:        NewDirectionalLight(mgl64.Vec3{0, 0, -2}, white, 0.5),
+			position:     mgl64.Vec3{5, 5, 5},
+			wantToLight:  mgl64.Vec3{0, 0, 1},
+			wantRadiance: 0.5,
+		},
+		{
+			name:         "point at the light",
+			light:        point,
+			position:     mgl64.Vec3{0, 2, 0},
+			wantToLight:  mgl64.Vec3{},
+			wantRadiance: 1,
+		},
+		{
+			// 1 + 0.5*2 + 0.25*2*2 = 3
+			name:         "point 2 away",
+			light:        point,
+			position:     mgl64.Vec3{0, 0, 0},
+			wantToLight:  mgl64.Vec3{0, 1, 0},
+			wantRadiance: 1.0 / 3,
+		},
+		{
+			// 1 + 0.5*4 + 0.25*4*4 = 7
+			name:         "point 4 away",
+			light:        point,
+			position:     mgl64.Vec3{0, -2, 0},
+			wantToLight:  mgl64.Vec3{0, 1, 0},
+			wantRadiance: 1.0 / 7,
+		},
+		{
+			name:         "spot in the middle of its cone",
+			light:        spot,
+			position:     mgl64.Vec3{0, 0, 0},
+			wantToLight:  mgl64.Vec3{0, 1, 0},
+			wantRadiance: 1,
+		},
+		{
+			// 30 degrees from the spot light's direction.
+			name:         "spot inside of the inner cone",
+			light:        spot,
+			position:     mgl64.Vec3{2 * math.Tan(math.Pi/6), 0, 0},
+			wantToLight:  mgl64.Vec3{-0.5, math.Sqrt(3) / 2, 0},
+			wantRadiance: 1,
+		},
+		{
+			// 75 degrees from the spot light's direction.
+			name:         "spot outside of the outer cone",
+			light:        spot,
+			position:     mgl64.Vec3{2 * math.Tan(5*math.Pi/12), 0, 0},
+			wantToLight:  mgl64.Vec3{-math.Sin(5 * math.Pi / 12), math.Cos(5 * math.Pi / 12), 0},
+			wantRadiance: 0,
+		},
+		{
+			name:         "spot behind the light",
+			light:        spot,
+			position:     mgl64.Vec3{0, 4, 0},
+			wantToLight:  mgl64.Vec3{0, -1, 0},
+			wantRadiance: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toLight, radiance := tt.light.Incident(tt.position)
+
+			if !toLight.ApproxEqual(tt.wantToLight) {
+				t.Errorf("got direction %v towards the light, want %v", toLight, tt.wantToLight)
+			}
+			if want := white.Mul(tt.wantRadiance); !radiance.ApproxEqual(want) {
+				t.Errorf("got radiance %v, want %v", radiance, want)
+			}
+		})
+	}
+}
+
+func TestSpotLightFalloff(t *testing.T) {
+	spot := NewSpotLight(mgl64.Vec3{0, 2, 0}, mgl64.Vec3{0, -1, 0}, math.Pi/4, math.Pi/3, mgl64.Vec3{1, 1, 1}, 1)
+	spot.Attenuation = mgl64.Vec3{1, 0, 0}
+
+	// Between the cones the light fades out the further it is from the
+	// spot light's direction.
+	last := 1.0
+	for deg := 46.0; deg < 60; deg += 2 {
+		_, radiance := spot.Incident(mgl64.Vec3{2 * math.Tan(mgl64.DegToRad(deg)), 0, 0})
+
+		if r := radiance.X(); r <= 0 || r >= last {
+			t.Errorf("got radiance %v at %v degrees, want it between 0 and %v", r, deg, last)
+		} else {
+			last = r
+		}
+	}
+}
+
+func TestLightTransform(t *testing.T) {
+	// The camera is on the +x axis looking back at the origin, so the
+	// world's +z axis points left in eye space and the world's -x axis
+	// points away from the camera.
+	view := mgl64.LookAtV(mgl64.Vec3{10, 0, 0}, mgl64.Vec3{}, mgl64.Vec3{0, 1, 0})
+
+	spot := NewSpotLight(mgl64.Vec3{0, 3, 5}, mgl64.Vec3{0, 0, -2}, 0.1, 0.2, mgl64.Vec3{1, 1, 1}, 1)
+	eye := spot.Transform(view)
+
+	if want := (mgl64.Vec3{-5, 3, -10}); !eye.Position.ApproxEqual(want) {
+		t.Errorf("got position %v, want %v", eye.Position, want)
+	}
+	// Directions aren't moved by the camera's position.
+	if want := (mgl64.Vec3{1, 0, 0}); !eye.Direction.ApproxEqual(want) {
+		t.Errorf("got direction %v, want %v", eye.Direction, want)
+	}
+	if eye.InnerCone != spot.InnerCone || eye.OuterCone != spot.OuterCone || eye.Attenuation != spot.Attenuation {
+		t.Errorf("got light %+v, want the same cones and attenuation as %+v", eye, spot)
+	}
+
+	// Moving the light and the point it lights into eye space together
+	// doesn't change the light that reaches the point.
+	world := mgl64.Vec3{0, 0, 4.5}
+	_, want := spot.Incident(world)
+	_, got := eye.Incident(view.Mul4x1(world.Vec4(1)).Vec3())
+	if !got.ApproxEqual(want) {
+		t.Errorf("got radiance %v in eye space, want %v", got, want)
+	}
+}
+
+func TestIlluminate(t *testing.T) {
+	up := mgl64.Vec3{0, 1, 0}
+	lights := []Light{
+		NewAmbientLight(mgl64.Vec3{1, 0, 0}, 0.25),
+		// Shines down on the point at 60 degrees from its normal.
+		NewDirectionalLight(mgl64.Vec3{math.Sqrt(3), -1, 0}, mgl64.Vec3{0, 1, 0}, 1),
+		// Shines from below the point, so it doesn't light it.
+		NewDirectionalLight(up, mgl64.Vec3{0, 0, 1}, 1),
+	}
+
+	tests := []struct {
+		name     string
+		position mgl64.Vec3
+		normal   mgl64.Vec3
+		want     mgl64.Vec3
+	}{
+		{"facing up", mgl64.Vec3{}, up, mgl64.Vec3{0.25, 0.5, 0}},
+		// Ambient light reaches the point no matter which way it's facing.
+		{"facing down", mgl64.Vec3{3, 3, 3}, up.Mul(-1), mgl64.Vec3{0.25, 0, 1}},
+		{"facing the light", mgl64.Vec3{}, mgl64.Vec3{-math.Sqrt(3), 1, 0}.Normalize(), mgl64.Vec3{0.25, 1, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Illuminate(lights, tt.position, tt.normal); !got.ApproxEqual(tt.want) {
+				t.Errorf("got light %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Illuminate(nil, mgl64.Vec3{}, up); got != (mgl64.Vec3{}) {
+		t.Errorf("got light %v without any lights, want none", got)
+	}
+}
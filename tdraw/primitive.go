@@ -0,0 +1,140 @@
+package tdraw
+
+import (
+	"image"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// Primitive is a triangle that has been clipped and projected onto the
+// screen, along with everything needed to draw it. Primitives are made with
+// AppendTriangle.
+type Primitive struct {
+	// vew holds the positions of the vertices on the screen, with their
+	// depth in z.
+	vew Triangle
+	// eye holds the positions of the vertices in eye space.
+	eye Triangle
+	// nrm, tex and col are the normals, texture coordinates and colors of
+	// the vertices.
+	nrm, tex, col Triangle
+	// dif and spc are the diffuse and specular light of the vertices.
+	dif, spc Triangle
+	// varyings are the shader's extra values of each vertex.
+	varyings [3]Varyings
+	// weights is the weight of each vertex when they're interpolated.
+	weights mgl64.Vec3
+
+	// face is the index of the triangle the primitive was made from.
+	face   int
+	shader FragmentShader
+
+	// alpha decides what the alpha of the pixels is used for, and cutoff is
+	// the alpha below which AlphaTest skips pixels.
+	alpha  AlphaMode
+	cutoff uint8
+}
+
+// Bounds returns the pixels that the primitive's bounding box covers.
+func (p *Primitive) Bounds() image.Rectangle {
+	return image.Rect(
+		int(math.Floor(math.Min(p.vew.A.X(), math.Min(p.vew.B.X(), p.vew.C.X())))),
+		int(math.Floor(math.Min(p.vew.A.Y(), math.Min(p.vew.B.Y(), p.vew.C.Y())))),
+		int(math.Ceil(math.Max(p.vew.A.X(), math.Max(p.vew.B.X(), p.vew.C.X()))))+1,
+		int(math.Ceil(math.Max(p.vew.A.Y(), math.Max(p.vew.B.Y(), p.vew.C.Y()))))+1,
+	)
+}
+
+// Draw draws the part of the primitive that is inside of rect onto the frame
+// buffer.
+//
+// Pixels are found with edge functions that are stepped incrementally across
+// the triangle's bounding box, and pixels on the edges follow the top-left
+// fill rule so triangles that share an edge don't draw over each other.
+func (p *Primitive) Draw(fb *FrameBuffer, rect image.Rectangle) {
+	// Scissor the triangle's bounding box to rect and the frame buffer, so
+	// only the pixels that are on the screen are drawn.
+	r := p.Bounds().Intersect(rect).Intersect(fb.Bounds())
+	if r.Empty() {
+		return
+	}
+	x0, y0 := float64(r.Min.X), float64(r.Min.Y)
+
+	// frag is reused for every pixel.
+	frag := Fragment{Face: p.face}
+
+	// Twice the signed area of the triangle, which is used to turn the edge
+	// functions into barycentric coordinates.
+	area := (p.vew.B.X()-p.vew.A.X())*(p.vew.C.Y()-p.vew.A.Y()) - (p.vew.B.Y()-p.vew.A.Y())*(p.vew.C.X()-p.vew.A.X())
+	if area == 0 {
+		return
+	}
+	sign := 1.0
+	if area < 0 {
+		sign, area = -1, -area
+	}
+
+	// Each edge is named after the vertex across from it, since the edge's
+	// function divided by the area is that vertex's barycentric weight.
+	var (
+		edgeA = newEdge(p.vew.B, p.vew.C, x0, y0, sign)
+		edgeB = newEdge(p.vew.C, p.vew.A, x0, y0, sign)
+		edgeC = newEdge(p.vew.A, p.vew.B, x0, y0, sign)
+	)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		// Start each row from the left of the bounding box, then step the
+		// edges along the row one pixel at a time.
+		n := y - r.Min.Y
+		ea, eb, ec := edgeA.at(n), edgeB.at(n), edgeC.at(n)
+
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if ea.inside() && eb.inside() && ec.inside() {
+				// bc is in the same order as BaryCenter returns.
+				bc := mgl64.Vec3{eb.w / area, ec.w / area, ea.w / area}
+
+				// The depth is already divided by w, so it's interpolated
+				// linearly across the screen.
+				z := bc.X()*p.vew.B.Z() + bc.Y()*p.vew.C.Z() + bc.Z()*p.vew.A.Z()
+
+				if i := fb.depthIndex(x, y); z > fb.Depth[i] {
+					// Every other attribute is interpolated with the
+					// corrected barycentric coordinate.
+					pc := correct(bc, p.weights)
+
+					frag.X, frag.Y, frag.Depth = x, y, z
+					frag.Eye = p.eye.interpolate(pc)
+					frag.Normal = p.nrm.interpolate(pc)
+					frag.UV = p.tex.interpolate(pc)
+					frag.Color = p.col.interpolate(pc)
+					frag.Diffuse = p.dif.interpolate(pc)
+					frag.Specular = p.spc.interpolate(pc)
+					for k := range frag.Varyings {
+						frag.Varyings[k] = pc.X()*p.varyings[1][k] + pc.Y()*p.varyings[2][k] + pc.Z()*p.varyings[0][k]
+					}
+
+					// Discarded pixels leave the depth alone too, so
+					// whatever is behind them still shows through.
+					if c, ok := p.shader.Fragment(&frag); ok {
+						switch {
+						case p.alpha == AlphaBlend:
+							// Blended pixels leave the depth alone so the
+							// ones behind them can still be drawn.
+							fb.Color.SetRGBA(x, y, blend(c, fb.Color.RGBAAt(x, y)))
+						case p.alpha == AlphaTest && c.A < p.cutoff:
+							// The pixel is cut out, so nothing is drawn.
+						default:
+							fb.Depth[i] = z
+							fb.Color.SetRGBA(x, y, c)
+						}
+					}
+				}
+			}
+
+			ea.w += ea.stepX
+			eb.w += eb.stepX
+			ec.w += ec.stepX
+		}
+	}
+}
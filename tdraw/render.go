@@ -13,6 +13,11 @@ type Triangle struct {
 	A, B, C mgl64.Vec3
 }
 
+// BaryCenter returns the barycentric coordinate of the pixel x, y in the
+// triangle, in the order B, C, A.
+//
+// Deprecated: Primitive.Draw finds pixels with edge functions instead, which
+// is faster and follows the top-left fill rule.
 func (t Triangle) BaryCenter(x, y int) mgl64.Vec3 {
 	p := mgl64.Vec3{float64(x), float64(y), 0.0}
 	v0 := t.B.Sub(t.A)
@@ -30,6 +35,8 @@ func (t Triangle) BaryCenter(x, y int) mgl64.Vec3 {
 }
 
 // PShade takes a color and applies shading to it by magnifying it's rgb values
+//
+// Deprecated: Use Shade, which lights each channel separately.
 func PShade(pixel color.Color, shading uint32) color.RGBA {
 	r, g, b, a := pixel.RGBA()
 	// r,g, and b are divided by 257 because the .RGBA() function returns
@@ -47,6 +54,22 @@ func PShade(pixel color.Color, shading uint32) color.RGBA {
 	return color.RGBA{uint8(r), uint8(g), uint8(b), uint8(a)}
 }
 
+// Shade takes a color and lights it by multiplying its red, green and blue
+// components with the light's, which usually range from 0 to 1 but can be
+// larger for bright lights. The alpha is left as it is.
+func Shade(pixel color.Color, light mgl64.Vec3) color.RGBA {
+	r, g, b, a := pixel.RGBA()
+
+	// The components from .RGBA() are on a 16 bit scale, so they're
+	// divided by 257 to get them back onto an 8 bit scale.
+	return color.RGBA{
+		R: uint8(math.Min(float64(r/257)*light.X(), 0xFF)),
+		G: uint8(math.Min(float64(g/257)*light.Y(), 0xFF)),
+		B: uint8(math.Min(float64(b/257)*light.Z(), 0xFF)),
+		A: uint8(a / 257),
+	}
+}
+
 func Unit(v mgl64.Vec3) mgl64.Vec3 {
 	return v.Mul(1.0 / v.Len())
 }
@@ -55,11 +78,25 @@ func (t Triangle) Unit() Triangle {
 	return Triangle{Unit(t.A), Unit(t.B), Unit(t.C)}
 }
 
+// interpolate blends the triangle's vertices with the barycentric coordinate
+// bc, which is in the order returned by BaryCenter.
+func (t *Triangle) interpolate(bc mgl64.Vec3) mgl64.Vec3 {
+	return mgl64.Vec3{
+		t.B[0]*bc[0] + t.C[0]*bc[1] + t.A[0]*bc[2],
+		t.B[1]*bc[0] + t.C[1]*bc[1] + t.A[1]*bc[2],
+		t.B[2]*bc[0] + t.C[2]*bc[1] + t.A[2]*bc[2],
+	}
+}
+
 // Mul returns a Triangle with each vertex magnified by f.
 func (t Triangle) Mul(f float64) Triangle {
 	return Triangle{t.A.Mul(f), t.B.Mul(f), t.C.Mul(f)}
 }
 
+// View projects the three points onto a viewport with mgl64.Project.
+//
+// Deprecated: Vertices are projected by AppendTriangle, which clips them
+// against the near and far planes first.
 func (t Triangle) View(obj1, obj2, obj3 mgl64.Vec3, modelview, proj mgl64.Mat4, initialX, initialY, w, h int) Triangle {
 	return Triangle{
 		B: mgl64.Project(obj1, modelview, proj, initialX, initialY, w, h),
@@ -68,6 +105,9 @@ func (t Triangle) View(obj1, obj2, obj3 mgl64.Vec3, modelview, proj mgl64.Mat4,
 	}
 }
 
+// Perspective divides the triangle by a fixed distance from the camera.
+//
+// Deprecated: Use a perspective matrix with AppendTriangle.
 func (t Triangle) Perspective() Triangle {
 	c := 3.0
 	za := 1.0 - t.A.Z()/c
@@ -80,8 +120,9 @@ func (t Triangle) Perspective() Triangle {
 	}
 }
 
-// More documentation needed in the rest of this file
-
+// ViewTri moves the triangle into the space of the x, y and z axes at eye.
+//
+// Deprecated: Multiply vertices by a model view matrix instead.
 func (t Triangle) ViewTri(x, y, z, eye mgl64.Vec3) Triangle {
 	return Triangle{
 		mgl64.Vec3{t.A.Dot(x) - x.Dot(eye), t.A.Dot(y) - y.Dot(eye), t.A.Dot(z) - z.Dot(eye)},
@@ -90,6 +131,10 @@ func (t Triangle) ViewTri(x, y, z, eye mgl64.Vec3) Triangle {
 	}
 }
 
+// ViewNrm moves the triangle's normals into the space of the x, y and z axes.
+//
+// Deprecated: Multiply normals by the inverse transpose of the model view
+// matrix instead.
 func (t Triangle) ViewNrm(x, y, z mgl64.Vec3) Triangle {
 	return Triangle{
 		mgl64.Vec3{t.A.Dot(x), t.A.Dot(y), t.A.Dot(z)},
@@ -98,6 +143,9 @@ func (t Triangle) ViewNrm(x, y, z mgl64.Vec3) Triangle {
 	}.Unit()
 }
 
+// Viewport maps the triangle onto a fixed part of a field.
+//
+// Deprecated: AppendTriangle maps vertices onto the whole buffer.
 func (t Triangle) Viewport(field floatgeom.Point2) Triangle {
 	w := field.Y() / 1.5
 	h := field.Y() / 1.5
@@ -110,46 +158,72 @@ func (t Triangle) Viewport(field floatgeom.Point2) Triangle {
 	}
 }
 
-// TDraw draws triangles onto the given buffer.
-func TDraw(buff *image.RGBA, zbuff [][]float64, vew, nrm, tex Triangle, textureData *image.RGBA) {
-	x0 := int(math.Min(vew.A.X(), math.Min(vew.B.X(), vew.C.X())))
-	y0 := int(math.Min(vew.A.Y(), math.Min(vew.B.Y(), vew.C.Y())))
-	x1 := int(math.Max(vew.A.X(), math.Max(vew.B.X(), vew.C.X())))
-	y1 := int(math.Max(vew.A.Y(), math.Max(vew.B.Y(), vew.C.Y())))
-	dims := textureData.Bounds()
+// Surface describes how the pixels of a triangle are colored before they are
+// shaded. The texture takes priority over the vertex colors, which take
+// priority over the flat color.
+type Surface struct {
+	// Texture is sampled with the triangle's texture coordinates.
+	Texture *image.RGBA
+	// VertexColors interpolates the colors of the triangle's vertices, with
+	// components from 0 to 1, across the triangle.
+	VertexColors bool
+	// Color is the flat color of the whole triangle, with its alpha
+	// multiplied in. The texture and vertex colors are tinted by it, so it
+	// should be white when they're meant to be used as they are.
+	Color color.RGBA
+}
 
-	// If the triangle is out of the screen's range then skip them entirely.
-	// TODO implement a good way to clip the triangles if they reach off the screen.
-	if (x0 < 0) || (x1 > len(zbuff)-1) {
-		return
+// Sample returns the color of the surface at a pixel with the given texture
+// coordinate and vertex color, which are interpolated from the triangle's
+// vertices.
+func (s *Surface) Sample(uv, rgb mgl64.Vec3) color.Color {
+	switch {
+	case s.Texture != nil:
+		dims := s.Texture.Bounds()
+		xx := (float64(dims.Max.X) - 1) * (0.0 + uv.X())
+		yy := (float64(dims.Max.Y) - 1) * (1.0 - uv.Y())
+		return tint(s.Texture.RGBAAt(int(xx), int(yy)), s.Color)
+	case s.VertexColors:
+		return tint(color.RGBA{
+			R: uint8(mgl64.Clamp(rgb.X(), 0, 1) * 0xFF),
+			G: uint8(mgl64.Clamp(rgb.Y(), 0, 1) * 0xFF),
+			B: uint8(mgl64.Clamp(rgb.Z(), 0, 1) * 0xFF),
+			A: 0xFF,
+		}, s.Color)
+	default:
+		return s.Color
 	}
-	if (y0 < 0) || (y1 > len(zbuff[0])-1) {
-		return
+}
+
+// tint multiplies the components of two colors together. Both colors have
+// their alpha multiplied in, and so does the result.
+func tint(c, t color.RGBA) color.RGBA {
+	return color.RGBA{
+		R: uint8(uint16(c.R) * uint16(t.R) / 0xFF),
+		G: uint8(uint16(c.G) * uint16(t.G) / 0xFF),
+		B: uint8(uint16(c.B) * uint16(t.B) / 0xFF),
+		A: uint8(uint16(c.A) * uint16(t.A) / 0xFF),
 	}
+}
 
-	for x := x0; x <= x1; x++ {
-		for y := y0; y <= y1; y++ {
-			bc := vew.BaryCenter(x, y)
-			if bc.X() >= 0.0 && bc.Y() >= 0.0 && bc.Z() >= 0.0 {
-				// Multiply everything by Z to create perspective.
-				z := bc.X()*vew.B.Z() + bc.Y()*vew.C.Z() + bc.Z()*vew.A.Z()
-
-				if z > zbuff[x][y] {
-					light := mgl64.Vec3{0.0, 0.0, 1.0}
-					varying := mgl64.Vec3{light.Dot(nrm.B), light.Dot(nrm.C), light.Dot(nrm.A)}
-
-					xx := (float64(dims.Max.X) - 1) * (0.0 + (bc.X()*tex.B.X() + bc.Y()*tex.C.X() + bc.Z()*tex.A.X()))
-					yy := (float64(dims.Max.Y) - 1) * (1.0 - (bc.X()*tex.B.Y() + bc.Y()*tex.C.Y() + bc.Z()*tex.A.Y()))
-					intensity := bc.Dot(varying)
-					var shading uint32
-					if intensity > 0.0 {
-						shading = uint32(intensity * 0xFF)
-					}
-					zbuff[x][y] = z
-
-					buff.Set(x, y, PShade(textureData.At(int(xx), int(yy)), shading))
-				}
-			}
-		}
+// TDraw draws triangles onto the given frame buffer, coloring them with the
+// given surface. nrm, tex and col are the normals, texture coordinates and
+// colors of the triangle's vertices, which are interpolated with the weight of
+// each vertex in weights, refer to Interpolation.
+//
+// The triangle is lit by a white light shining from the camera. Only the part
+// of the triangle that is on the buffer is drawn, but the triangle must
+// already be clipped against the near plane, refer to DrawTriangle.
+func TDraw(fb *FrameBuffer, vew, nrm, tex, col Triangle, weights mgl64.Vec3, surface Surface) {
+	// TDraw doesn't know where the triangle is in eye space, so it can only
+	// be lit by the headlight.
+	p := Primitive{
+		vew:     vew,
+		nrm:     nrm,
+		tex:     tex,
+		col:     col,
+		weights: weights,
+		shader:  &surfaceShader{surface: surface, lights: []Light{Headlight}},
 	}
+	p.Draw(fb, fb.Bounds())
 }
@@ -0,0 +1,217 @@
+package tdraw
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// gridTriangles returns the screen positions of triangles that tile a buffer
+// of the given width and height, splitting it into squares of size pixels
+// that are each cut in half along a diagonal.
+func gridTriangles(w, h, size int) []Triangle {
+	var tris []Triangle
+	for y := 0; y < h; y += size {
+		for x := 0; x < w; x += size {
+			var (
+				x0, y0 = float64(x), float64(y)
+				x1, y1 = float64(x + size), float64(y + size)
+				// The depth changes across the buffer so the depth test
+				// isn't always decided the same way.
+				z = 0.25 + 0.5*float64(x+y)/float64(w+h)
+			)
+			tris = append(tris,
+				Triangle{mgl64.Vec3{x0, y0, z}, mgl64.Vec3{x0, y1, z}, mgl64.Vec3{x1, y0, z}},
+				Triangle{mgl64.Vec3{x1, y0, z}, mgl64.Vec3{x0, y1, z}, mgl64.Vec3{x1, y1, z}},
+			)
+		}
+	}
+
+	return tris
+}
+
+// drawBaryCenter draws a triangle the way TDraw used to, by finding the
+// barycentric coordinate of every pixel in the triangle's bounding box with
+// BaryCenter. It's kept to compare TDraw against.
+func drawBaryCenter(fb *FrameBuffer, vew, nrm, tex, col Triangle, weights mgl64.Vec3, surface Surface) {
+	shader := &surfaceShader{surface: surface, lights: []Light{Headlight}}
+	bounds := fb.Bounds()
+
+	x0 := math.Floor(math.Min(vew.A.X(), math.Min(vew.B.X(), vew.C.X())))
+	y0 := math.Floor(math.Min(vew.A.Y(), math.Min(vew.B.Y(), vew.C.Y())))
+	x1 := math.Ceil(math.Max(vew.A.X(), math.Max(vew.B.X(), vew.C.X())))
+	y1 := math.Ceil(math.Max(vew.A.Y(), math.Max(vew.B.Y(), vew.C.Y())))
+
+	x0 = math.Max(x0, 0)
+	y0 = math.Max(y0, 0)
+	x1 = math.Min(x1, float64(bounds.Dx()-1))
+	y1 = math.Min(y1, float64(bounds.Dy()-1))
+
+	var frag Fragment
+	for x := int(x0); x <= int(x1); x++ {
+		for y := int(y0); y <= int(y1); y++ {
+			bc := vew.BaryCenter(x, y)
+			if bc.X() < 0 || bc.Y() < 0 || bc.Z() < 0 {
+				continue
+			}
+
+			z := bc.X()*vew.B.Z() + bc.Y()*vew.C.Z() + bc.Z()*vew.A.Z()
+			if i := fb.depthIndex(x, y); z > fb.Depth[i] {
+				pc := correct(bc, weights)
+
+				frag.X, frag.Y, frag.Depth = x, y, z
+				frag.Normal = nrm.interpolate(pc)
+				frag.UV = tex.interpolate(pc)
+				frag.Color = col.interpolate(pc)
+
+				if c, ok := shader.Fragment(&frag); ok {
+					fb.Depth[i] = z
+					fb.Color.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkTDraw(b *testing.B) {
+	const w, h = 1600, 900
+
+	var (
+		tris    = gridTriangles(w, h, 20)
+		nrm     = Triangle{mgl64.Vec3{0, 0, 1}, mgl64.Vec3{0, 0, 1}, mgl64.Vec3{0, 0, 1}}
+		col     = Triangle{mgl64.Vec3{1, 0, 0}, mgl64.Vec3{0, 1, 0}, mgl64.Vec3{0, 0, 1}}
+		weights = mgl64.Vec3{1, 1, 1}
+		surface = Surface{VertexColors: true, Color: color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}}
+	)
+
+	benchmarks := []struct {
+		name string
+		draw func(fb *FrameBuffer, vew, nrm, tex, col Triangle, weights mgl64.Vec3, surface Surface)
+	}{
+		{"EdgeFunctions", TDraw},
+		{"BaryCenter", drawBaryCenter},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			fb := NewFrameBuffer(w, h)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				fb.Clear()
+				for _, vew := range tris {
+					bm.draw(fb, vew, nrm, Triangle{}, col, weights, surface)
+				}
+			}
+		})
+	}
+}
+
+// countingShader counts how many times every pixel is shaded. It discards
+// every pixel, so the depth buffer never stops a pixel from being shaded
+// again.
+type countingShader struct {
+	counts map[image.Point]int
+}
+
+func (s *countingShader) Fragment(f *Fragment) (color.RGBA, bool) {
+	s.counts[image.Pt(f.X, f.Y)]++
+	return color.RGBA{}, false
+}
+
+func TestTopLeftRule(t *testing.T) {
+	tests := []struct {
+		name string
+		// polygon is a convex polygon on the screen, which is split into a
+		// fan of triangles around its center so that every triangle shares
+		// its edges with its neighbours.
+		polygon []mgl64.Vec2
+	}{
+		{
+			name:    "square on pixel corners",
+			polygon: []mgl64.Vec2{{10, 10}, {30, 10}, {30, 30}, {10, 30}},
+		},
+		{
+			name:    "counter-clockwise square",
+			polygon: []mgl64.Vec2{{10, 10}, {10, 30}, {30, 30}, {30, 10}},
+		},
+		{
+			name:    "square between pixels",
+			polygon: []mgl64.Vec2{{10.5, 10.25}, {30.5, 10.25}, {30.5, 30.75}, {10.5, 30.75}},
+		},
+		{
+			name:    "hexagon",
+			polygon: []mgl64.Vec2{{20, 4}, {34, 12}, {34, 28}, {20, 36}, {6, 28}, {6, 12}},
+		},
+		{
+			name:    "thin sliver",
+			polygon: []mgl64.Vec2{{2, 2}, {60, 3}, {61, 4}, {3, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var center mgl64.Vec2
+			for _, v := range tt.polygon {
+				center = center.Add(v)
+			}
+			center = center.Mul(1 / float64(len(tt.polygon)))
+
+			fb := NewFrameBuffer(64, 64)
+			shader := &countingShader{counts: make(map[image.Point]int)}
+
+			for i, a := range tt.polygon {
+				b := tt.polygon[(i+1)%len(tt.polygon)]
+				p := Primitive{
+					vew: Triangle{
+						center.Vec3(0.5),
+						a.Vec3(0.5),
+						b.Vec3(0.5),
+					},
+					weights: mgl64.Vec3{1, 1, 1},
+					shader:  shader,
+				}
+				p.Draw(fb, fb.Bounds())
+			}
+
+			for pt, n := range shader.counts {
+				if n > 1 {
+					t.Errorf("pixel %v was drawn %d times", pt, n)
+				}
+			}
+
+			// The triangles must still cover the polygon without leaving
+			// gaps between them.
+			for y := 0; y < 64; y++ {
+				for x := 0; x < 64; x++ {
+					pt := image.Pt(x, y)
+					if insideConvex(tt.polygon, mgl64.Vec2{float64(x), float64(y)}) && shader.counts[pt] == 0 {
+						t.Errorf("pixel %v inside of the polygon wasn't drawn", pt)
+					}
+				}
+			}
+		})
+	}
+}
+
+// insideConvex returns whether p is strictly inside of a convex polygon.
+func insideConvex(polygon []mgl64.Vec2, p mgl64.Vec2) bool {
+	var pos, neg bool
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		cross := (b.X()-a.X())*(p.Y()-a.Y()) - (b.Y()-a.Y())*(p.X()-a.X())
+		switch {
+		case cross > 0:
+			pos = true
+		case cross < 0:
+			neg = true
+		default:
+			return false
+		}
+	}
+
+	return pos != neg
+}
@@ -0,0 +1,86 @@
+package tdraw
+
+import (
+	"image/color"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// MaxVaryings is the number of extra values that a vertex can hand to the
+// fragment stage on top of its position, normal, texture coordinate, color
+// and light.
+const MaxVaryings = 8
+
+// Varyings are extra values that are set for each vertex by the vertex stage
+// and interpolated across the triangle for the fragment stage, such as a
+// dissolve threshold or a fog factor. What each of them means is up to the
+// shader.
+type Varyings [MaxVaryings]float64
+
+// Fragment is a pixel of a triangle that is about to be drawn, along with
+// the vertex attributes interpolated to that pixel.
+type Fragment struct {
+	// X and Y are the pixel's position on the buffer, and Depth is its depth,
+	// which is larger closer to the camera.
+	X, Y  int
+	Depth float64
+	// Face is the index of the triangle the pixel belongs to, as it was given
+	// to AppendTriangle.
+	Face int
+
+	// Eye, Normal, UV, Color, Diffuse and Specular are the interpolated
+	// attributes of the triangle's vertices. The normal isn't normalized
+	// after being interpolated.
+	Eye      mgl64.Vec3
+	Normal   mgl64.Vec3
+	UV       mgl64.Vec3
+	Color    mgl64.Vec3
+	Diffuse  mgl64.Vec3
+	Specular mgl64.Vec3
+	Varyings Varyings
+}
+
+// VertexShader is the vertex stage of a shader.
+type VertexShader interface {
+	// Vertex returns the vertex at index of the mesh being drawn, with its
+	// position moved into clip space and the attributes and varyings that
+	// the fragment stage needs filled in.
+	Vertex(index int) Vertex
+}
+
+// FragmentShader is the fragment stage of a shader.
+type FragmentShader interface {
+	// Fragment returns the color of a pixel of a triangle. When ok is false
+	// the pixel is discarded, leaving both the color and depth of the
+	// buffer alone.
+	Fragment(f *Fragment) (c color.RGBA, ok bool)
+}
+
+// Shader decides where a mesh's vertices end up and what color the pixels
+// between them are. Shaders are run by many workers at once, so they must be
+// safe to use concurrently.
+type Shader interface {
+	VertexShader
+	FragmentShader
+}
+
+// surfaceShader is the fragment stage that colors pixels with a surface and
+// lights them with the lights in eye space.
+type surfaceShader struct {
+	surface Surface
+	lights  []Light
+}
+
+func (s *surfaceShader) Fragment(f *Fragment) (color.RGBA, bool) {
+	nrm := f.Normal
+	if l := nrm.Len(); l > 0 {
+		nrm = nrm.Mul(1 / l)
+	}
+
+	return Shade(s.surface.Sample(f.UV, f.Color), Illuminate(s.lights, f.Eye, nrm)), true
+}
+
+// Headlight is a white light in eye space shining from the camera in the
+// direction it's looking, which is what lights things when nothing else
+// does.
+var Headlight = NewDirectionalLight(mgl64.Vec3{0, 0, -1}, mgl64.Vec3{1, 1, 1}, 1)
@@ -0,0 +1,31 @@
+package tdraw
+
+// Shading decides how often the lighting of a triangle is worked out, which
+// changes how smooth or faceted it looks.
+type Shading int
+
+const (
+	// PhongShading interpolates the vertex normals across the triangle and
+	// lights every pixel, giving smooth surfaces and sharp highlights.
+	PhongShading Shading = iota
+	// GouraudShading lights each vertex and blends the light across the
+	// triangle, which is cheaper but blurs highlights.
+	GouraudShading
+	// FlatShading lights the whole triangle with the normal of its face,
+	// giving the faceted look of low poly art.
+	FlatShading
+)
+
+// String returns the name of the shading.
+func (s Shading) String() string {
+	switch s {
+	case PhongShading:
+		return "phong"
+	case GouraudShading:
+		return "gouraud"
+	case FlatShading:
+		return "flat"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,19 @@
+package tdraw
+
+// DrawState is the fixed function state that a triangle is drawn with, which
+// decides which triangles are drawn and how their pixels are written. The
+// zero value draws every triangle with perspective correct interpolation and
+// writes every pixel as opaque.
+type DrawState struct {
+	// Cull decides which triangles are skipped based on the way they're
+	// facing.
+	Cull CullMode
+	// Interpolation decides how the attributes of the vertices are blended
+	// across the triangle.
+	Interpolation Interpolation
+	// Alpha decides what the alpha of the pixels is used for.
+	Alpha AlphaMode
+	// AlphaCutoff is the alpha from 0 to 1 below which AlphaTest skips
+	// pixels.
+	AlphaCutoff float64
+}
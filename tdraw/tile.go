@@ -0,0 +1,82 @@
+package tdraw
+
+import (
+	"image"
+	"sync"
+)
+
+// TileSize is the width and height in pixels of the tiles that DrawTiled
+// splits the buffer into.
+const TileSize = 32
+
+// DrawTiled draws the primitives onto the frame buffer using the given number
+// of workers.
+//
+// The buffer is split into tiles and every primitive is binned into each of
+// the tiles its bounding box overlaps. Workers are then handed whole tiles,
+// so no two workers ever touch the same pixel, and every tile draws its
+// primitives in the order they were given. This makes the result the same
+// no matter how the workers are scheduled.
+func DrawTiled(fb *FrameBuffer, prims []Primitive, workers int) {
+	bounds := fb.Bounds()
+	if bounds.Empty() {
+		return
+	}
+
+	cols := (bounds.Dx() + TileSize - 1) / TileSize
+	rows := (bounds.Dy() + TileSize - 1) / TileSize
+
+	// bins holds the indices of the primitives that overlap each tile, in
+	// the order the primitives were given.
+	bins := make([][]int, cols*rows)
+	for i := range prims {
+		r := prims[i].Bounds().Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+
+		r = r.Sub(bounds.Min)
+		for ty := r.Min.Y / TileSize; ty <= (r.Max.Y-1)/TileSize; ty++ {
+			for tx := r.Min.X / TileSize; tx <= (r.Max.X-1)/TileSize; tx++ {
+				bins[ty*cols+tx] = append(bins[ty*cols+tx], i)
+			}
+		}
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		tiles = make(chan int)
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for t := range tiles {
+				min := bounds.Min.Add(image.Pt(t%cols, t/cols).Mul(TileSize))
+				rect := image.Rectangle{min, min.Add(image.Pt(TileSize, TileSize))}
+
+				for _, i := range bins[t] {
+					prims[i].Draw(fb, rect)
+				}
+			}
+		}()
+	}
+
+	for t := range bins {
+		if len(bins[t]) > 0 {
+			tiles <- t
+		}
+	}
+
+	// Close the tiles channel to signal that the workers may stop.
+	close(tiles)
+
+	// Wait for all the workers to finish their work.
+	wg.Wait()
+}
@@ -7,7 +7,11 @@ import (
 // Camera is a 3-Dimensional camera object with a perspective transform to apply
 // to the around around us.
 //
-// TODO more documentation on camera structure.
+// The camera sits at its position and looks along its forward direction, with
+// its up direction pointing towards the top of the screen. Forward is a
+// direction rather than a point, so a camera at (1, 0, 1) with a forward of
+// (0, 0, 1) looks down the +z axis, not at the point (0, 0, 1). Use
+// NewLookAtCamera to point a camera at a target instead.
 type Camera struct {
 	// if the window is resized then the aspect ratio won't work
 	// and then the perspective matrix will break.
@@ -23,7 +27,7 @@ type Camera struct {
 // NewExplicitCamera returns a new camera with a transform perspective matrix.
 // The parameters include:
 // 1. pos - position of the camera in the world space
-// 2. forward - what the camera perceives as forward
+// 2. forward - the direction the camera looks in, relative to its position
 // 3. up - what the camera perceives as up
 // 4. fovy - the camera's field of vision
 // 5. aspect - the aspect ratio of the viewport. (width ÷ height)
@@ -44,7 +48,8 @@ func NewExplicitCamera(pos, forward, up mgl64.Vec3, fovy, aspect, zNear, zFar fl
 	}
 }
 
-// NewCamera is a somewhat defaulted value camera.
+// NewCamera is a somewhat defaulted value camera, which looks down the +z axis
+// from the given position.
 func NewCamera(pos mgl64.Vec3, fovy, aspect float64) *Camera {
 	return NewExplicitCamera(
 		pos,                 // Position of the camera.
@@ -57,6 +62,20 @@ func NewCamera(pos mgl64.Vec3, fovy, aspect float64) *Camera {
 	)
 }
 
+// NewLookAtCamera returns a camera at pos that looks towards target, with the
+// same defaults as NewCamera.
+func NewLookAtCamera(pos, target mgl64.Vec3, fovy, aspect float64) *Camera {
+	return NewExplicitCamera(
+		pos,
+		target.Sub(pos),     // Look from the position towards the target.
+		mgl64.Vec3{0, 1, 0}, // Y is what we perceive is up.
+		fovy,
+		aspect,
+		0.01,
+		1000,
+	)
+}
+
 // GetForwardRotation returns what the camera perceives is forward in the world.
 func (c *Camera) GetForwardRotation() mgl64.Vec3 {
 	return c.forward
@@ -1,86 +1,115 @@
 package view
 
 import (
-	"image"
+	"image/color"
 	"sync"
 
 	"github.com/damienfamed75/pine/tdraw"
 	"github.com/go-gl/mathgl/mgl64"
 )
 
-// workerPackage contains all the data necessary to render pixels on to the
-// given buffer.
+// workerPackage contains all the data necessary to turn the model's
+// triangles into primitives that are ready to be drawn.
 type workerPackage struct {
-	x, y, z mgl64.Vec3
-	eye     mgl64.Vec3
-
-	spriteDimensions *image.Point
-
-	transform mgl64.Mat4
-	proj      mgl64.Mat4
-
-	outUVs      []mgl64.Vec3
-	outVertices []mgl64.Vec3
-	outNormals  []mgl64.Vec3
-
-	zbuff [][]float64
-
-	spriteRGBA  *image.RGBA
-	textureData *image.RGBA
+	// shader is the model's shader bound to the model's uniforms.
+	shader tdraw.Shader
+	// vertices is the number of vertices in the model.
+	vertices int
+
+	// state decides which faces are skipped, how the attributes are blended
+	// across faces and what the alpha of the pixels is used for.
+	state tdraw.DrawState
+	// faceMaterials holds the material of every face. Faces with a material
+	// that can be seen through are always blended.
+	faceMaterials []*Material
+
+	// w and h are the size of the buffer being drawn to.
+	w, h int
+	// batchSize is the number of triangles in every batch.
+	batchSize int
+
+	// batches and blendBatches hold the opaque and blended primitives of
+	// every batch of triangles, in the same order as the triangles.
+	batches, blendBatches [][]tdraw.Primitive
 }
 
-// For every triangle in the model.
+// For every batch of triangles in the model.
 //
-// Loop loops every three vertices, because we need three vertices to build
-// a triangle to render.
-func drawingWorker(pkg *workerPackage, indices chan int, wg *sync.WaitGroup) {
-	var (
-		mvert, mnrm, mtex tdraw.Triangle
-	)
-
-	for i := range indices {
-
-		// Vertex Normals.
-		mnrm = tdraw.Triangle{
-			A: pkg.outNormals[i],
-			B: pkg.outNormals[i+1],
-			C: pkg.outNormals[i+2],
-		}.ViewNrm(pkg.x, pkg.y, pkg.z)
-
-		// Model Coordinates.
-		mvert = tdraw.Triangle{
-			A: pkg.outVertices[i],
-			B: pkg.outVertices[i+1],
-			C: pkg.outVertices[i+2],
-		}.ViewTri(pkg.x, pkg.y, pkg.z, pkg.eye)
-
-		// Texture Coordinates.
-		mtex = tdraw.Triangle{
-			A: pkg.outUVs[i],
-			B: pkg.outUVs[i+1],
-			C: pkg.outUVs[i+2],
+// Each batch's triangles are run through the vertex stage of the shader,
+// then clipped, culled and projected onto the screen, and the primitives
+// left over are kept in the batch's slot so they're drawn in the same order
+// no matter which worker got to them first.
+func drawingWorker(pkg *workerPackage, batches chan int, wg *sync.WaitGroup) {
+	for b := range batches {
+		var (
+			prims, blended []tdraw.Primitive
+			start          = b * pkg.batchSize * 3
+			end            = start + pkg.batchSize*3
+		)
+		if end > pkg.vertices {
+			end = pkg.vertices
 		}
 
-		// Perspective Vertices.
-		vew := tdraw.Triangle{
-			A: mgl64.Project(
-				mvert.A, pkg.transform, pkg.proj, 0, 0, pkg.spriteDimensions.X, pkg.spriteDimensions.Y),
-			B: mgl64.Project(
-				mvert.B, pkg.transform, pkg.proj, 0, 0, pkg.spriteDimensions.X, pkg.spriteDimensions.Y),
-			C: mgl64.Project(
-				mvert.C, pkg.transform, pkg.proj, 0, 0, pkg.spriteDimensions.X, pkg.spriteDimensions.Y),
+		// Loop loops every three vertices, because we need three vertices to
+		// build a triangle to render.
+		for i := start; i < end; i += 3 {
+			tri := [3]tdraw.Vertex{
+				pkg.shader.Vertex(i),
+				pkg.shader.Vertex(i + 1),
+				pkg.shader.Vertex(i + 2),
+			}
+
+			state := pkg.state
+			if mat := pkg.faceMaterials[i/3]; mat != nil && mat.Dissolve < 1 {
+				state.Alpha = tdraw.AlphaBlend
+			}
+
+			// Clip the triangle and project what is left of it onto the
+			// buffer.
+			if state.Alpha == tdraw.AlphaBlend {
+				blended = tdraw.AppendTriangle(blended, pkg.w, pkg.h, i/3, tri, pkg.shader, state)
+			} else {
+				prims = tdraw.AppendTriangle(prims, pkg.w, pkg.h, i/3, tri, pkg.shader, state)
+			}
 		}
 
-		// Draw the triangles into the buffer.
-		tdraw.TDraw(
-			pkg.spriteRGBA,
-			pkg.zbuff,
-			vew,
-			mnrm,
-			mtex,
-			pkg.textureData,
-		)
+		pkg.batches[b] = prims
+		pkg.blendBatches[b] = blended
 	}
 
 	wg.Done()
 }
+
+// vecToRGBA converts an rgb color with components from 0 to 1 into a
+// color.RGBA with the given alpha.
+func vecToRGBA(rgb mgl64.Vec3, alpha float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(mgl64.Clamp(rgb.X(), 0, 1) * 0xFF),
+		G: uint8(mgl64.Clamp(rgb.Y(), 0, 1) * 0xFF),
+		B: uint8(mgl64.Clamp(rgb.Z(), 0, 1) * 0xFF),
+		A: uint8(mgl64.Clamp(alpha, 0, 1) * 0xFF),
+	}
+}
+
+// premultiply converts an rgb color with components from 0 to 1 into a
+// color.RGBA with the given alpha multiplied into it.
+func premultiply(rgb mgl64.Vec3, alpha float64) color.RGBA {
+	alpha = mgl64.Clamp(alpha, 0, 1)
+	return color.RGBA{
+		R: uint8(mgl64.Clamp(rgb.X(), 0, 1) * alpha * 0xFF),
+		G: uint8(mgl64.Clamp(rgb.Y(), 0, 1) * alpha * 0xFF),
+		B: uint8(mgl64.Clamp(rgb.Z(), 0, 1) * alpha * 0xFF),
+		A: uint8(alpha * 0xFF),
+	}
+}
+
+// rgbaToVec converts a color into an rgb color with components from 0 to 1,
+// and its alpha from 0 to 1.
+func rgbaToVec(c color.Color) (rgb mgl64.Vec3, alpha float64) {
+	r, g, b, a := c.RGBA()
+	return mgl64.Vec3{
+		float64(r) / 0xFFFF,
+		float64(g) / 0xFFFF,
+		float64(b) / 0xFFFF,
+	}, float64(a) / 0xFFFF
+}
@@ -0,0 +1,828 @@
+package view
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/disintegration/gift"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// GLTFLoader loads glTF 2.0 models, either as .gltf files with external or
+// base64 embedded buffers and images, or as binary .glb files.
+// The zero value is ready to use.
+type GLTFLoader struct {
+	// Open opens the external buffers and images referenced by a model.
+	// When Open is nil files are opened from the OS.
+	Open OpenFunc
+
+	// TextureFilters are run over every texture the loader loads.
+	// Textures aren't filtered when there aren't any filters.
+	TextureFilters []gift.Filter
+}
+
+// glTF component types of accessors.
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+// glTF primitive modes that are made out of triangles.
+const (
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+// glb header and chunk identifiers.
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\x00"
+)
+
+// gltfDocument is the JSON structure of a glTF file, limited to the parts
+// that are needed to draw the model.
+type gltfDocument struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+}
+
+type gltfNode struct {
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Matrix      []float64 `json:"matrix"`
+	Translation []float64 `json:"translation"`
+	Rotation    []float64 `json:"rotation"`
+	Scale       []float64 `json:"scale"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"`
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness struct {
+		BaseColorFactor  []float64       `json:"baseColorFactor"`
+		BaseColorTexture *gltfTextureRef `json:"baseColorTexture"`
+		MetallicFactor   *float64        `json:"metallicFactor"`
+		RoughnessFactor  *float64        `json:"roughnessFactor"`
+	} `json:"pbrMetallicRoughness"`
+	EmissiveFactor []float64 `json:"emissiveFactor"`
+}
+
+type gltfTextureRef struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source *int `json:"source"`
+}
+
+type gltfImage struct {
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+}
+
+// gltfDecoder holds the state of a single glTF file while it's being turned
+// into a model.
+type gltfDecoder struct {
+	loader *GLTFLoader
+	doc    *gltfDocument
+	// name is the name of the file being loaded.
+	name string
+	// bin is the binary chunk of a .glb file.
+	bin []byte
+
+	buffers  map[int][]byte
+	textures map[int]*Texture
+}
+
+// LoadGLTF loads a .gltf or .glb file into memory with a GLTFLoader.
+func LoadGLTF(file string, w, h int, camera *Camera) (*Model, error) {
+	return new(GLTFLoader).Load(file, w, h, camera)
+}
+
+// LoadGLTFReader reads a .gltf or .glb file from r with a GLTFLoader.
+// Refer to GLTFLoader.LoadReader for more information.
+func LoadGLTFReader(r io.Reader, name string, w, h int, camera *Camera) (*Model, error) {
+	return new(GLTFLoader).LoadReader(r, name, w, h, camera)
+}
+
+// Load loads a .gltf or .glb file into memory, including its buffers and
+// the base color textures of its materials.
+//
+// Every mesh of the default scene is added to the model with the transforms
+// of the nodes above it already applied, so the node hierarchy is flattened
+// into a single mesh. Each material becomes a Material named after the
+// glTF material, or "material<index>" when it doesn't have a name.
+func (l *GLTFLoader) Load(file string, w, h int, camera *Camera) (*Model, error) {
+	f, err := l.Open.open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return l.LoadReader(f, file, w, h, camera)
+}
+
+// LoadReader reads a .gltf or .glb file from r, the same way Load loads a
+// file. name is used in errors and to find external buffers and images,
+// which are opened relative to its directory.
+func (l *GLTFLoader) LoadReader(r io.Reader, name string, w, h int, camera *Camera) (*Model, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := &gltfDecoder{
+		loader:   l,
+		doc:      new(gltfDocument),
+		name:     name,
+		buffers:  make(map[int][]byte),
+		textures: make(map[int]*Texture),
+	}
+
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if data, dec.bin, err = parseGLB(data); err != nil {
+			return nil, dec.errorf("%v", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, dec.doc); err != nil {
+		return nil, dec.errorf("%v", err)
+	}
+	if !strings.HasPrefix(dec.doc.Asset.Version, "2.") {
+		return nil, dec.errorf("unsupported glTF version %q", dec.doc.Asset.Version)
+	}
+
+	mod := newModel(w, h, camera)
+
+	materials := make([]*Material, len(dec.doc.Materials))
+	for i := range dec.doc.Materials {
+		if materials[i], err = dec.material(i); err != nil {
+			return nil, err
+		}
+		mod.materials[materials[i].Name] = materials[i]
+	}
+
+	for _, node := range dec.sceneNodes() {
+		if err := dec.addNode(mod, materials, node, mgl64.Ident4(), 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return mod, nil
+}
+
+// parseGLB splits a binary .glb file into its JSON and binary chunks.
+func parseGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb header is too short")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %d", version)
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, errors.New("glb file is truncated")
+	}
+
+	for offset := 12; offset+8 <= length; {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+
+		if chunkLength < 0 || offset+chunkLength > length {
+			return nil, nil, errors.New("glb chunk is truncated")
+		}
+		chunk := data[offset : offset+chunkLength]
+		offset += chunkLength
+
+		switch {
+		case chunkType == glbChunkJSON && jsonChunk == nil:
+			jsonChunk = chunk
+		case chunkType == glbChunkBIN && binChunk == nil:
+			binChunk = chunk
+		}
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb file has no JSON chunk")
+	}
+
+	return jsonChunk, binChunk, nil
+}
+
+// errorf returns an error that mentions the file being loaded.
+func (d *gltfDecoder) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("%s: %s", d.name, fmt.Sprintf(format, a...))
+}
+
+// sceneNodes returns the root nodes of the default scene. Files without any
+// scenes have every node that isn't a child of another node drawn instead.
+func (d *gltfDecoder) sceneNodes() []int {
+	if len(d.doc.Scenes) > 0 {
+		scene := 0
+		if d.doc.Scene != nil && *d.doc.Scene >= 0 && *d.doc.Scene < len(d.doc.Scenes) {
+			scene = *d.doc.Scene
+		}
+		return d.doc.Scenes[scene].Nodes
+	}
+
+	isChild := make([]bool, len(d.doc.Nodes))
+	for _, node := range d.doc.Nodes {
+		for _, child := range node.Children {
+			if child >= 0 && child < len(isChild) {
+				isChild[child] = true
+			}
+		}
+	}
+
+	var roots []int
+	for i := range d.doc.Nodes {
+		if !isChild[i] {
+			roots = append(roots, i)
+		}
+	}
+
+	return roots
+}
+
+// addNode adds the mesh of a node and of all its children to the model.
+// parent is the world transform of the node's parent.
+func (d *gltfDecoder) addNode(mod *Model, materials []*Material, idx int, parent mgl64.Mat4, depth int) error {
+	if idx < 0 || idx >= len(d.doc.Nodes) {
+		return d.errorf("node %d doesn't exist", idx)
+	}
+	// A valid node hierarchy can never be deeper than the amount of nodes,
+	// so anything deeper must contain a cycle.
+	if depth > len(d.doc.Nodes) {
+		return d.errorf("node %d is part of a cycle", idx)
+	}
+
+	node := d.doc.Nodes[idx]
+	world := parent.Mul4(node.localTransform())
+
+	if node.Mesh != nil {
+		if *node.Mesh < 0 || *node.Mesh >= len(d.doc.Meshes) {
+			return d.errorf("node %d: mesh %d doesn't exist", idx, *node.Mesh)
+		}
+		for i, prim := range d.doc.Meshes[*node.Mesh].Primitives {
+			if err := d.addPrimitive(mod, materials, prim, world); err != nil {
+				return d.errorf("mesh %d: primitive %d: %v", *node.Mesh, i, err)
+			}
+		}
+	}
+
+	for _, child := range node.Children {
+		if err := d.addNode(mod, materials, child, world, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// localTransform returns the transform of the node relative to its parent.
+func (n gltfNode) localTransform() mgl64.Mat4 {
+	if len(n.Matrix) == 16 {
+		// glTF matrices are column major, the same as mgl64.
+		var m mgl64.Mat4
+		copy(m[:], n.Matrix)
+		return m
+	}
+
+	transform := mgl64.Ident4()
+	if len(n.Translation) == 3 {
+		transform = mgl64.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	}
+	if len(n.Rotation) == 4 {
+		// glTF rotations are stored as x, y, z, w.
+		rot := mgl64.Quat{
+			W: n.Rotation[3],
+			V: mgl64.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]},
+		}
+		transform = transform.Mul4(rot.Normalize().Mat4())
+	}
+	if len(n.Scale) == 3 {
+		transform = transform.Mul4(mgl64.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2]))
+	}
+
+	return transform
+}
+
+// addPrimitive adds the triangles of a primitive to the model after moving
+// them into world space.
+func (d *gltfDecoder) addPrimitive(mod *Model, materials []*Material, prim gltfPrimitive, world mgl64.Mat4) error {
+	mode := gltfTriangles
+	if prim.Mode != nil {
+		mode = *prim.Mode
+	}
+	// Points and lines don't have any surface to draw.
+	if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+		return nil
+	}
+
+	posIdx, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return errors.New("missing POSITION attribute")
+	}
+	positions, err := d.readVecs(posIdx, 3)
+	if err != nil {
+		return err
+	}
+
+	// Every attribute must have an element for each position, since they're
+	// all looked up with the same indices.
+	var normals, uvs []mgl64.Vec3
+	if idx, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, err = d.readVecs(idx, 3); err != nil {
+			return err
+		}
+		if len(normals) < len(positions) {
+			return fmt.Errorf("NORMAL has %d elements, POSITION has %d", len(normals), len(positions))
+		}
+	}
+	if idx, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		if uvs, err = d.readVecs(idx, 2); err != nil {
+			return err
+		}
+		if len(uvs) < len(positions) {
+			return fmt.Errorf("TEXCOORD_0 has %d elements, POSITION has %d", len(uvs), len(positions))
+		}
+	}
+
+	var material *Material
+	if prim.Material != nil {
+		if *prim.Material < 0 || *prim.Material >= len(materials) {
+			return fmt.Errorf("material %d doesn't exist", *prim.Material)
+		}
+		material = materials[*prim.Material]
+	}
+
+	// Primitives without indices use every vertex in order.
+	var indices []int
+	if prim.Indices != nil {
+		values, err := d.readAccessor(*prim.Indices, 1)
+		if err != nil {
+			return err
+		}
+		indices = make([]int, len(values))
+		for i, v := range values {
+			if indices[i] = int(v); indices[i] < 0 || indices[i] >= len(positions) {
+				return fmt.Errorf("index %d out of range, %d vertices", indices[i], len(positions))
+			}
+		}
+	} else {
+		indices = make([]int, len(positions))
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	// Normals are moved into world space with the inverse transpose so that
+	// non-uniform scales don't skew them. Negative scales flip the winding
+	// order of the triangles, so the winding is flipped back.
+	normalMat := world.Mat3().Inv().Transpose()
+	flip := world.Det() < 0
+
+	for _, tri := range gltfTriangleIndices(mode, indices) {
+		if flip {
+			tri[1], tri[2] = tri[2], tri[1]
+		}
+
+		var verts [3]mgl64.Vec3
+		for i, idx := range tri {
+			verts[i] = world.Mul4x1(positions[idx].Vec4(1)).Vec3()
+		}
+		// glTF requires flat normals when normals aren't given.
+		flat := verts[1].Sub(verts[0]).Cross(verts[2].Sub(verts[0]))
+		if l := flat.Len(); l > 0 {
+			flat = flat.Mul(1 / l)
+		}
+
+		for i, idx := range tri {
+			mod.outVertices = append(mod.outVertices, verts[i])
+
+			normal := flat
+			if normals != nil {
+				normal = normalMat.Mul3x1(normals[idx])
+				if l := normal.Len(); l > 0 {
+					normal = normal.Mul(1 / l)
+				}
+			}
+			mod.outNormals = append(mod.outNormals, normal)
+
+			var uv mgl64.Vec3
+			if uvs != nil {
+				// glTF texture coordinates start at the top of the image,
+				// while the renderer expects them to start at the bottom.
+				uv = mgl64.Vec3{uvs[idx].X(), 1 - uvs[idx].Y(), 0}
+			}
+			mod.outUVs = append(mod.outUVs, uv)
+		}
+
+		mod.faceMaterials = append(mod.faceMaterials, material)
+	}
+
+	return nil
+}
+
+// gltfTriangleIndices splits the indices of a primitive into triangles.
+func gltfTriangleIndices(mode int, indices []int) [][3]int {
+	var tris [][3]int
+
+	switch mode {
+	case gltfTriangles:
+		for i := 0; i+2 < len(indices); i += 3 {
+			tris = append(tris, [3]int{indices[i], indices[i+1], indices[i+2]})
+		}
+	case gltfTriangleStrip:
+		// Every other triangle in a strip is wound the opposite way.
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				tris = append(tris, [3]int{indices[i], indices[i+1], indices[i+2]})
+			} else {
+				tris = append(tris, [3]int{indices[i+1], indices[i], indices[i+2]})
+			}
+		}
+	case gltfTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			tris = append(tris, [3]int{indices[0], indices[i], indices[i+1]})
+		}
+	}
+
+	return tris
+}
+
+// material converts a glTF material into a Material.
+func (d *gltfDecoder) material(idx int) (*Material, error) {
+	src := d.doc.Materials[idx]
+
+	name := src.Name
+	if name == "" {
+		name = fmt.Sprintf("material%d", idx)
+	}
+
+	mat := newMaterial(name)
+	pbr := src.PBRMetallicRoughness
+
+	// The base color factor tints the base color texture, the same way the
+	// diffuse color of an .mtl material tints its diffuse texture.
+	mat.Diffuse = mgl64.Vec3{1, 1, 1}
+	if len(pbr.BaseColorFactor) == 4 {
+		mat.Diffuse = mgl64.Vec3{pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2]}
+		mat.Dissolve = pbr.BaseColorFactor[3]
+	}
+
+	// Metals reflect their own color while everything else reflects a small
+	// amount of white light, and rougher surfaces have wider highlights.
+	metallic, roughness := 1.0, 1.0
+	if pbr.MetallicFactor != nil {
+		metallic = *pbr.MetallicFactor
+	}
+	if pbr.RoughnessFactor != nil {
+		roughness = *pbr.RoughnessFactor
+	}
+	dielectric := mgl64.Vec3{0.04, 0.04, 0.04}
+	mat.Specular = dielectric.Add(mat.Diffuse.Sub(dielectric).Mul(metallic))
+	mat.Shininess = math.Max(2/math.Max(math.Pow(roughness, 4), 1e-4)-2, 1)
+
+	if len(src.EmissiveFactor) == 3 {
+		mat.Emissive = mgl64.Vec3{src.EmissiveFactor[0], src.EmissiveFactor[1], src.EmissiveFactor[2]}
+	}
+
+	if pbr.BaseColorTexture != nil {
+		tex, err := d.texture(pbr.BaseColorTexture.Index)
+		if err != nil {
+			return nil, d.errorf("material %d: %v", idx, err)
+		}
+		mat.DiffuseMap = tex
+	}
+
+	return mat, nil
+}
+
+// texture loads the image of a glTF texture.
+func (d *gltfDecoder) texture(idx int) (*Texture, error) {
+	if idx < 0 || idx >= len(d.doc.Textures) {
+		return nil, fmt.Errorf("texture %d doesn't exist", idx)
+	}
+	src := d.doc.Textures[idx].Source
+	if src == nil {
+		return nil, fmt.Errorf("texture %d has no image", idx)
+	}
+	if *src < 0 || *src >= len(d.doc.Images) {
+		return nil, fmt.Errorf("image %d doesn't exist", *src)
+	}
+
+	if tex, ok := d.textures[*src]; ok {
+		return tex, nil
+	}
+
+	img := d.doc.Images[*src]
+
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case img.BufferView != nil:
+		data, err = d.bufferView(*img.BufferView)
+	case strings.HasPrefix(img.URI, "data:"):
+		data, err = decodeDataURI(img.URI)
+	default:
+		data, err = d.readFile(img.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %v", *src, err)
+	}
+
+	tex, err := DecodeTexture(bytes.NewReader(data), d.loader.TextureFilters...)
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %v", *src, err)
+	}
+	d.textures[*src] = tex
+
+	return tex, nil
+}
+
+// readFile reads a file referenced by a URI relative to the glTF file.
+func (d *gltfDecoder) readFile(uri string) ([]byte, error) {
+	ref, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := d.loader.Open.open(d.loader.Open.join(d.loader.Open.dir(d.name), ref))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ioutil.ReadAll(f)
+}
+
+// decodeDataURI returns the data embedded in a base64 data URI.
+func decodeDataURI(uri string) ([]byte, error) {
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+		return nil, errors.New("only base64 data URIs are supported")
+	}
+
+	return base64.StdEncoding.DecodeString(uri[comma+1:])
+}
+
+// buffer returns the contents of a buffer.
+func (d *gltfDecoder) buffer(idx int) ([]byte, error) {
+	if data, ok := d.buffers[idx]; ok {
+		return data, nil
+	}
+	if idx < 0 || idx >= len(d.doc.Buffers) {
+		return nil, fmt.Errorf("buffer %d doesn't exist", idx)
+	}
+
+	var (
+		buf  = d.doc.Buffers[idx]
+		data []byte
+		err  error
+	)
+	switch {
+	case buf.URI == "":
+		// Buffers without a uri refer to the binary chunk of a .glb file.
+		if d.bin == nil {
+			return nil, fmt.Errorf("buffer %d has no uri", idx)
+		}
+		data = d.bin
+	case strings.HasPrefix(buf.URI, "data:"):
+		data, err = decodeDataURI(buf.URI)
+	default:
+		data, err = d.readFile(buf.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("buffer %d: %v", idx, err)
+	}
+	if len(data) < buf.ByteLength {
+		return nil, fmt.Errorf("buffer %d is %d bytes, expected %d", idx, len(data), buf.ByteLength)
+	}
+
+	d.buffers[idx] = data
+	return data, nil
+}
+
+// bufferView returns the bytes that a buffer view covers.
+func (d *gltfDecoder) bufferView(idx int) ([]byte, error) {
+	if idx < 0 || idx >= len(d.doc.BufferViews) {
+		return nil, fmt.Errorf("buffer view %d doesn't exist", idx)
+	}
+	view := d.doc.BufferViews[idx]
+
+	data, err := d.buffer(view.Buffer)
+	if err != nil {
+		return nil, err
+	}
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset > len(data)-view.ByteLength {
+		return nil, fmt.Errorf("buffer view %d is out of range of buffer %d", idx, view.Buffer)
+	}
+
+	return data[view.ByteOffset : view.ByteOffset+view.ByteLength], nil
+}
+
+// readVecs reads an accessor with up to three components per element into
+// vectors, leaving any missing components at 0.
+func (d *gltfDecoder) readVecs(idx, components int) ([]mgl64.Vec3, error) {
+	values, err := d.readAccessor(idx, components)
+	if err != nil {
+		return nil, err
+	}
+
+	vecs := make([]mgl64.Vec3, len(values)/components)
+	for i := range vecs {
+		copy(vecs[i][:], values[i*components:(i+1)*components])
+	}
+
+	return vecs, nil
+}
+
+// readAccessor reads every element of an accessor as floats, returning
+// components floats per element. Normalized integers are converted into the
+// range they represent.
+func (d *gltfDecoder) readAccessor(idx, components int) ([]float64, error) {
+	if idx < 0 || idx >= len(d.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %d doesn't exist", idx)
+	}
+	acc := d.doc.Accessors[idx]
+
+	if len(acc.Sparse) > 0 {
+		return nil, fmt.Errorf("accessor %d: sparse accessors aren't supported", idx)
+	}
+	if n := gltfTypeComponents[acc.Type]; n != components {
+		return nil, fmt.Errorf("accessor %d: expected %d components, got type %q", idx, components, acc.Type)
+	}
+	size := gltfComponentSizes[acc.ComponentType]
+	if size == 0 {
+		return nil, fmt.Errorf("accessor %d: unknown component type %d", idx, acc.ComponentType)
+	}
+
+	if acc.Count < 0 {
+		return nil, fmt.Errorf("accessor %d: negative count %d", idx, acc.Count)
+	}
+	if acc.ByteOffset < 0 {
+		return nil, fmt.Errorf("accessor %d: negative byte offset %d", idx, acc.ByteOffset)
+	}
+
+	// Accessors without a buffer view are all zeros. They have no data to
+	// check their count against, so it's capped instead.
+	if acc.BufferView == nil {
+		if acc.Count > gltfMaxZeroCount {
+			return nil, fmt.Errorf("accessor %d: count %d is too large for an accessor without a buffer view", idx, acc.Count)
+		}
+		return make([]float64, acc.Count*components), nil
+	}
+
+	data, err := d.bufferView(*acc.BufferView)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %v", idx, err)
+	}
+
+	elemSize := size * components
+	stride := d.doc.BufferViews[*acc.BufferView].ByteStride
+	if stride < 0 {
+		return nil, fmt.Errorf("accessor %d: buffer view %d has a negative byte stride %d", idx, *acc.BufferView, stride)
+	}
+	if stride == 0 {
+		stride = elemSize
+	}
+
+	// Every element must fit in the buffer view before anything is
+	// allocated, which is worked out without multiplying the count so that
+	// huge counts can't overflow.
+	last := len(data) - acc.ByteOffset - elemSize
+	if acc.Count > 0 && (last < 0 || (acc.Count-1) > last/stride) {
+		return nil, fmt.Errorf("accessor %d is out of range of buffer view %d", idx, *acc.BufferView)
+	}
+
+	values := make([]float64, acc.Count*components)
+	for i := 0; i < acc.Count; i++ {
+		elem := data[acc.ByteOffset+i*stride:]
+		for c := 0; c < components; c++ {
+			values[i*components+c] = readComponent(elem[c*size:], acc.ComponentType, acc.Normalized)
+		}
+	}
+
+	return values, nil
+}
+
+// gltfMaxZeroCount is the most elements an accessor without a buffer view
+// may have, which keeps malformed files from allocating without limit.
+const gltfMaxZeroCount = 1 << 24
+
+// gltfTypeComponents is the amount of components of each accessor type.
+var gltfTypeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+}
+
+// gltfComponentSizes is the size in bytes of each component type.
+var gltfComponentSizes = map[int]int{
+	gltfByte:          1,
+	gltfUnsignedByte:  1,
+	gltfShort:         2,
+	gltfUnsignedShort: 2,
+	gltfUnsignedInt:   4,
+	gltfFloat:         4,
+}
+
+// readComponent reads a single little endian component.
+func readComponent(b []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case gltfByte:
+		v := float64(int8(b[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case gltfUnsignedByte:
+		v := float64(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gltfShort:
+		v := float64(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(b))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+}
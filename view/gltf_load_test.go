@@ -0,0 +1,177 @@
+package view
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// testGLTF returns a glTF file with a single triangle. The buffer holds the
+// triangle's 3 positions, followed by 2 normals and 2 texture coordinates.
+// attributes and accessors are the primitive's attributes and the file's
+// accessors, as JSON.
+func testGLTF(attributes, accessors string) string {
+	var buf bytes.Buffer
+	for _, f := range []float32{
+		0, 0, 0, 1, 0, 0, 0, 1, 0, // POSITION
+		0, 0, 1, 0, 0, 1, // NORMAL
+		0, 0, 1, 0, // TEXCOORD_0
+	} {
+		binary.Write(&buf, binary.LittleEndian, f)
+	}
+
+	return fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"scenes": [{"nodes": [0]}],
+	"nodes": [{"mesh": 0}],
+	"meshes": [{"primitives": [{"attributes": {%s}}]}],
+	"accessors": [%s],
+	"bufferViews": [{"buffer": 0, "byteLength": %d}],
+	"buffers": [{"uri": "data:application/octet-stream;base64,%s", "byteLength": %d}]
+}`, attributes, accessors, buf.Len(), base64.StdEncoding.EncodeToString(buf.Bytes()), buf.Len())
+}
+
+// Accessors into the buffer of testGLTF.
+const (
+	gltfPositions = `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`
+	gltfNormals   = `{"bufferView": 0, "byteOffset": 36, "componentType": 5126, "count": 2, "type": "VEC3"}`
+	gltfUVs       = `{"bufferView": 0, "byteOffset": 60, "componentType": 5126, "count": 2, "type": "VEC2"}`
+)
+
+func TestLoadGLTFTriangle(t *testing.T) {
+	src := testGLTF(`"POSITION": 0`, gltfPositions)
+
+	m, err := LoadGLTFReader(strings.NewReader(src), "tri.gltf", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.outVertices); got != 3 {
+		t.Errorf("got %d vertices, want 3", got)
+	}
+}
+
+func TestLoadGLTFMalformed(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		accessors  []string
+		wantErr    string
+	}{
+		{
+			name:       "fewer normals than positions",
+			attributes: `"POSITION": 0, "NORMAL": 1`,
+			accessors:  []string{gltfPositions, gltfNormals},
+			wantErr:    "NORMAL has 2 elements, POSITION has 3",
+		},
+		{
+			name:       "fewer texture coordinates than positions",
+			attributes: `"POSITION": 0, "TEXCOORD_0": 1`,
+			accessors:  []string{gltfPositions, gltfUVs},
+			wantErr:    "TEXCOORD_0 has 2 elements, POSITION has 3",
+		},
+		{
+			name:       "negative accessor count",
+			attributes: `"POSITION": 0`,
+			accessors:  []string{`{"bufferView": 0, "componentType": 5126, "count": -3, "type": "VEC3"}`},
+			wantErr:    "accessor 0: negative count -3",
+		},
+		{
+			name:       "negative accessor count without a buffer view",
+			attributes: `"POSITION": 0`,
+			accessors:  []string{`{"componentType": 5126, "count": -3, "type": "VEC3"}`},
+			wantErr:    "accessor 0: negative count -3",
+		},
+		{
+			name:       "huge accessor count without a buffer view",
+			attributes: `"POSITION": 0`,
+			accessors:  []string{`{"componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`},
+			wantErr:    "accessor 0: count 4611686018427387904 is too large",
+		},
+		{
+			name:       "negative accessor byte offset",
+			attributes: `"POSITION": 0`,
+			accessors:  []string{`{"bufferView": 0, "byteOffset": -4, "componentType": 5126, "count": 3, "type": "VEC3"}`},
+			wantErr:    "accessor 0: negative byte offset -4",
+		},
+		{
+			name:       "accessor past the end of its buffer view",
+			attributes: `"POSITION": 0`,
+			accessors:  []string{`{"bufferView": 0, "byteOffset": 48, "componentType": 5126, "count": 3, "type": "VEC3"}`},
+			wantErr:    "accessor 0 is out of range of buffer view 0",
+		},
+		{
+			// The count would overflow when multiplied by the stride.
+			name:       "huge accessor count",
+			attributes: `"POSITION": 0`,
+			accessors:  []string{`{"bufferView": 0, "componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`},
+			wantErr:    "accessor 0 is out of range of buffer view 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := testGLTF(tt.attributes, strings.Join(tt.accessors, ","))
+
+			_, err := LoadGLTFReader(strings.NewReader(src), "bad.gltf", 1, 1, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), "bad.gltf: ") {
+				t.Errorf("error %q doesn't start with the file name", err)
+			}
+		})
+	}
+}
+
+func TestLoadGLTFBaseColor(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(testGLTF(`"POSITION": 0`, gltfPositions)), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// The base color factor is yellow, which takes the blue out of the pink
+	// texture when they're multiplied together.
+	texture := base64.StdEncoding.EncodeToString([]byte(pngFile(t, 2, 2, color.RGBA{0xFF, 0x80, 0xFF, 0xFF})))
+	mesh := doc["meshes"].([]interface{})[0].(map[string]interface{})
+	mesh["primitives"].([]interface{})[0].(map[string]interface{})["material"] = 0
+	doc["materials"] = []interface{}{map[string]interface{}{
+		"pbrMetallicRoughness": map[string]interface{}{
+			"baseColorFactor":  []float64{1, 1, 0, 1},
+			"baseColorTexture": map[string]interface{}{"index": 0},
+			"metallicFactor":   0,
+		},
+	}}
+	doc["textures"] = []interface{}{map[string]interface{}{"source": 0}}
+	doc["images"] = []interface{}{map[string]interface{}{"uri": "data:image/png;base64," + texture}}
+
+	src, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const w, h = 16, 16
+	camera := NewLookAtCamera(mgl64.Vec3{0.25, 0.25, 1}, mgl64.Vec3{0.25, 0.25, 0}, mgl64.DegToRad(90), 1)
+	m, err := LoadGLTFReader(bytes.NewReader(src), "tri.gltf", w, h, camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mat := m.GetMaterial("material0")
+	if mat == nil || mat.DiffuseMap == nil || mat.Diffuse != (mgl64.Vec3{1, 1, 0}) {
+		t.Fatalf("got material %+v, want the base color factor and texture", mat)
+	}
+
+	// The triangle faces the light, so it's lit enough to tell the colors
+	// apart.
+	img, _ := RenderImage(m, camera, w, h)
+	if c := img.RGBAAt(w/2, h/2); c.R < 0xC0 || c.G < 0x60 || c.G > 0xA0 || c.B > 0x20 {
+		t.Errorf("got %v in the middle of the triangle, want the texture tinted yellow", c)
+	}
+}
@@ -0,0 +1,129 @@
+package view
+
+import (
+	"math"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// Material describes how the surface of a group of faces should be colored.
+// Materials are usually loaded from a Wavefront .mtl file that is referenced
+// by an .obj file with the mtllib directive.
+type Material struct {
+	// Name is the name given to the material by newmtl.
+	Name string
+
+	Ambient  mgl64.Vec3 // Ka - ambient color.
+	Diffuse  mgl64.Vec3 // Kd - diffuse color.
+	Specular mgl64.Vec3 // Ks - specular color.
+	Emissive mgl64.Vec3 // Ke - emissive color.
+
+	// Shininess is the specular exponent (Ns) of the material. Larger values
+	// give smaller and sharper highlights, and 0 turns them off.
+	Shininess float64
+	// Dissolve is how opaque the material is (d). 1 is fully opaque, and
+	// faces with a lower dissolve are always blended with what's behind them.
+	Dissolve float64
+
+	DiffuseMap  *Texture // map_Kd - diffuse texture.
+	BumpMap     *Texture // map_Bump - bump texture.
+	SpecularMap *Texture // map_Ks - specular texture.
+}
+
+// newMaterial returns a material with the same defaults that the .mtl
+// specification uses for values a material doesn't declare.
+func newMaterial(name string) *Material {
+	return &Material{
+		Name:     name,
+		Ambient:  mgl64.Vec3{0.2, 0.2, 0.2},
+		Diffuse:  mgl64.Vec3{0.8, 0.8, 0.8},
+		Specular: mgl64.Vec3{1, 1, 1},
+		Dissolve: 1,
+	}
+}
+
+// newBaseMaterial returns the material of faces that don't have one, which
+// keeps the colors of the faces and reflects all of the ambient light, but
+// has no highlights.
+func newBaseMaterial() *Material {
+	return &Material{
+		Name:     "base",
+		Ambient:  mgl64.Vec3{1, 1, 1},
+		Diffuse:  mgl64.Vec3{1, 1, 1},
+		Dissolve: 1,
+	}
+}
+
+// Shade lights a point on a surface of the material with the Blinn-Phong
+// lighting model, returning its color with components that usually range
+// from 0 to 1. base is the color of the surface at the point, such as a texel
+// of the diffuse texture, which is lit by the diffuse light and reflects the
+// ambient light by the material's ambient color. The position, normal and
+// viewer, which is where the camera is, must all be in the same space as the
+// lights, and the normal must be a unit vector.
+func (mat *Material) Shade(base mgl64.Vec3, lights []tdraw.Light, position, normal, viewer mgl64.Vec3) mgl64.Vec3 {
+	diffuse, specular := mat.Reflect(lights, position, normal, viewer)
+	return mat.Combine(base, diffuse, specular)
+}
+
+// Reflect returns the light reflected by a point on a surface of the
+// material, split into the diffuse light that colors the surface, including
+// the ambient light, and the specular light of its highlights. Shade gives the
+// same result as Combine with the light from Reflect, but the light can be
+// worked out per vertex and blended across faces before it's combined.
+func (mat *Material) Reflect(lights []tdraw.Light, position, normal, viewer mgl64.Vec3) (diffuse, specular mgl64.Vec3) {
+	var ambient mgl64.Vec3
+
+	var toViewer mgl64.Vec3
+	if mat.Shininess > 0 {
+		toViewer = viewer.Sub(position)
+		if l := toViewer.Len(); l > 0 {
+			toViewer = toViewer.Mul(1 / l)
+		}
+	}
+
+	for _, l := range lights {
+		toLight, radiance := l.Incident(position)
+		if l.Kind == tdraw.AmbientLight {
+			ambient = ambient.Add(radiance)
+			continue
+		}
+
+		d := normal.Dot(toLight)
+		if d <= 0 {
+			// The light is behind the surface, so it can't be seen and
+			// doesn't give a highlight either.
+			continue
+		}
+		diffuse = diffuse.Add(radiance.Mul(d))
+
+		if mat.Shininess <= 0 {
+			continue
+		}
+		// The highlight is brightest when the normal is halfway between the
+		// directions to the light and to the viewer.
+		half := toLight.Add(toViewer)
+		if hl := half.Len(); hl > 0 {
+			if s := normal.Dot(half) / hl; s > 0 {
+				specular = specular.Add(radiance.Mul(math.Pow(s, mat.Shininess)))
+			}
+		}
+	}
+
+	return mulVec(ambient, mat.Ambient).Add(diffuse), mulVec(specular, mat.Specular)
+}
+
+// Combine returns the color of a point on a surface of the material with the
+// color base, lit by the diffuse and specular light from Reflect. base
+// mustn't have its alpha multiplied in. Multiply the alpha into the result
+// instead, so the highlights fade along with the surface.
+func (mat *Material) Combine(base, diffuse, specular mgl64.Vec3) mgl64.Vec3 {
+	return mat.Emissive.Add(mulVec(base, diffuse)).Add(specular)
+}
+
+// mulVec multiplies the components of a and b together, the way that colors
+// are mixed.
+func mulVec(a, b mgl64.Vec3) mgl64.Vec3 {
+	return mgl64.Vec3{a[0] * b[0], a[1] * b[1], a[2] * b[2]}
+}
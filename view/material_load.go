@@ -0,0 +1,186 @@
+package view
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// LoadMtl loads a .mtl file into memory with a lenient ObjLoader.
+func LoadMtl(mtlFile string) (map[string]*Material, error) {
+	return new(ObjLoader).LoadMtl(mtlFile)
+}
+
+// LoadMtlReader reads a .mtl file from r with a lenient ObjLoader.
+// Refer to ObjLoader.LoadMtlReader for more information.
+func LoadMtlReader(r io.Reader, name string) (map[string]*Material, error) {
+	return new(ObjLoader).LoadMtlReader(r, name)
+}
+
+// LoadMtl loads a Wavefront .mtl file into memory, returning every material
+// it declares keyed by name. Texture maps are loaded relative to the
+// directory of the .mtl file.
+//
+// newmtl - starts a new material
+// Ka - ambient color
+// Kd - diffuse color
+// Ks - specular color
+// Ke - emissive color
+// Ns - specular exponent
+// d - dissolve (opacity)
+// Tr - transparency, which is the opposite of dissolve
+// map_Kd - diffuse texture
+// map_Bump - bump texture
+// map_Ks - specular texture
+//
+// Problems with the contents of the file are returned as a *ParseError.
+func (l *ObjLoader) LoadMtl(mtlFile string) (map[string]*Material, error) {
+	fmtl, err := l.Open.open(mtlFile)
+	if err != nil {
+		return nil, err
+	}
+	defer fmtl.Close()
+
+	return l.LoadMtlReader(fmtl, mtlFile)
+}
+
+// LoadMtlReader reads a .mtl file from r, the same way LoadMtl loads a file.
+// name is used in errors and to find the texture maps, which are opened
+// relative to its directory.
+func (l *ObjLoader) LoadMtlReader(r io.Reader, name string) (map[string]*Material, error) {
+	var (
+		materials = make(map[string]*Material)
+		current   *Material
+		lineNum   int
+		err       error
+	)
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		lineNum++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		// problem reports what is wrong with the current line.
+		problem := func(err error) error {
+			return l.report(&ParseError{
+				File:      name,
+				Line:      lineNum,
+				Directive: fields[0],
+				Reason:    err.Error(),
+				Err:       err,
+			})
+		}
+
+		if fields[0] == "newmtl" {
+			current = newMaterial(strings.Join(fields[1:], " "))
+			materials[current.Name] = current
+			continue
+		}
+		// Every other directive belongs to a material, so skip anything that
+		// appears before the first newmtl.
+		if current == nil {
+			if err := problem(fmt.Errorf("no material declared with newmtl")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch fields[0] {
+		case "Ka":
+			err = setVec(&current.Ambient, fields[1:])
+		case "Kd":
+			err = setVec(&current.Diffuse, fields[1:])
+		case "Ks":
+			err = setVec(&current.Specular, fields[1:])
+		case "Ke":
+			err = setVec(&current.Emissive, fields[1:])
+		case "Ns":
+			err = setFloat(&current.Shininess, fields[1:])
+		case "d":
+			err = setFloat(&current.Dissolve, fields[1:])
+		case "Tr":
+			// Some exporters write the transparency instead of the dissolve.
+			tr := 1 - current.Dissolve
+			err = setFloat(&tr, fields[1:])
+			current.Dissolve = 1 - tr
+		case "map_Kd":
+			current.DiffuseMap, err = l.loadTextureMap(name, fields)
+		case "map_Bump", "map_bump", "bump":
+			current.BumpMap, err = l.loadTextureMap(name, fields)
+		case "map_Ks":
+			current.SpecularMap, err = l.loadTextureMap(name, fields)
+		}
+
+		if err != nil {
+			if err := problem(err); err != nil {
+				return nil, err
+			}
+			err = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// The line after the last one that was read couldn't be read.
+		return nil, &ParseError{File: name, Line: lineNum + 1, Reason: err.Error(), Err: err}
+	}
+
+	return materials, nil
+}
+
+// setVec sets dst to the three numbers of a directive. dst is left alone when
+// the numbers are malformed, so lenient loaders keep the default.
+func setVec(dst *mgl64.Vec3, fields []string) error {
+	v, err := parseVec(fields, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	*dst = v
+	return nil
+}
+
+// setFloat sets dst to the number of a directive that takes a single number.
+// dst is left alone when the number is malformed.
+func setFloat(dst *float64, fields []string) error {
+	f, err := parseFloat(fields)
+	if err != nil {
+		return err
+	}
+
+	*dst = f
+	return nil
+}
+
+// parseFloat parses a directive that takes a single number.
+func parseFloat(fields []string) (float64, error) {
+	if len(fields) != 1 {
+		return 0, fmt.Errorf("expected 1 number, got %d", len(fields))
+	}
+
+	f, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", fields[0])
+	}
+
+	return f, nil
+}
+
+// loadTextureMap loads the texture referenced by a map_* directive.
+// Texture map options such as -bm or -s come before the file name, so the
+// file name is always the last field of the directive.
+func (l *ObjLoader) loadTextureMap(mtlFile string, fields []string) (*Texture, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("missing texture file")
+	}
+
+	return l.loadTexture(l.Open.dir(mtlFile), fields[len(fields)-1])
+}
@@ -0,0 +1,333 @@
+package view
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// filledImage returns a w by h image filled with c.
+func filledImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < w*h; i++ {
+		img.Set(i%w, i/w, c)
+	}
+
+	return img
+}
+
+// pngFile returns a w by h .png file filled with c.
+func pngFile(t *testing.T, w, h int, c color.Color) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, filledImage(w, h, c)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestLoadMtl(t *testing.T) {
+	defaults := newMaterial("")
+
+	tests := []struct {
+		name string
+		mtl  string
+		// want is the material named "m", which starts with the defaults.
+		want func(m *Material)
+	}{
+		{
+			name: "defaults",
+			mtl:  "newmtl m",
+			want: func(m *Material) {},
+		},
+		{
+			name: "colors",
+			mtl:  "newmtl m\nKa 0.1 0.2 0.3\nKd 0.4 0.5 0.6\nKs 0.7 0.8 0.9\nKe 1 0.5 0",
+			want: func(m *Material) {
+				m.Ambient = mgl64.Vec3{0.1, 0.2, 0.3}
+				m.Diffuse = mgl64.Vec3{0.4, 0.5, 0.6}
+				m.Specular = mgl64.Vec3{0.7, 0.8, 0.9}
+				m.Emissive = mgl64.Vec3{1, 0.5, 0}
+			},
+		},
+		{
+			name: "shininess",
+			mtl:  "newmtl m\nNs 96.5",
+			want: func(m *Material) { m.Shininess = 96.5 },
+		},
+		{
+			name: "dissolve",
+			mtl:  "newmtl m\nd 0.25",
+			want: func(m *Material) { m.Dissolve = 0.25 },
+		},
+		{
+			name: "transparency",
+			mtl:  "newmtl m\nTr 0.25",
+			want: func(m *Material) { m.Dissolve = 0.75 },
+		},
+		{
+			name: "comments and blank lines",
+			mtl:  "# a library\n\nnewmtl m\n  # Kd 1 0 0\n\tNs 2\n",
+			want: func(m *Material) { m.Shininess = 2 },
+		},
+		{
+			// Directives belong to the material declared last.
+			name: "many materials",
+			mtl:  "newmtl m\nKd 1 0 0\nnewmtl other\nKd 0 1 0",
+			want: func(m *Material) { m.Diffuse = mgl64.Vec3{1, 0, 0} },
+		},
+		{
+			// Unknown directives such as illumination models are ignored.
+			name: "unknown directives",
+			mtl:  "newmtl m\nillum 2\nNi 1.5",
+			want: func(m *Material) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := new(ObjLoader)
+			materials, err := l.LoadMtlReader(strings.NewReader(tt.mtl), "lib.mtl")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(l.Warnings) > 0 {
+				t.Fatalf("got warnings %v", l.Warnings)
+			}
+
+			want := *defaults
+			want.Name = "m"
+			tt.want(&want)
+			if got := materials["m"]; got == nil || *got != want {
+				t.Errorf("got material %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadMtlNames(t *testing.T) {
+	const mtl = "newmtl first\nnewmtl with spaces\nnewmtl  first \nKd 0 0 1\n"
+
+	materials, err := LoadMtlReader(strings.NewReader(mtl), "lib.mtl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(materials) != 2 {
+		t.Errorf("got %d materials, want 2", len(materials))
+	}
+	if m := materials["with spaces"]; m == nil || m.Name != "with spaces" {
+		t.Errorf(`got material %+v, want one named "with spaces"`, m)
+	}
+	// Declaring a material again starts it over.
+	if m := materials["first"]; m == nil || m.Diffuse != (mgl64.Vec3{0, 0, 1}) {
+		t.Errorf("got material %+v, want the first material declared again", m)
+	}
+}
+
+func TestLoadMtlTextureMaps(t *testing.T) {
+	l := &ObjLoader{Open: memoryOpen(map[string]string{
+		"models/lib.mtl": `newmtl m
+map_Kd -bm 0.5 -s 1 1 1 textures/diffuse.png
+map_Bump bump.png
+map_Ks ../specular.png
+newmtl shared
+map_Kd textures/diffuse.png
+`,
+		"models/textures/diffuse.png": pngFile(t, 2, 1, color.White),
+		"models/bump.png":             pngFile(t, 3, 1, color.White),
+		"specular.png":                pngFile(t, 4, 1, color.White),
+	})}
+
+	materials, err := l.LoadMtl("models/lib.mtl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Warnings) > 0 {
+		t.Fatalf("got warnings %v", l.Warnings)
+	}
+
+	// The maps are told apart by their widths.
+	m := materials["m"]
+	for _, tt := range []struct {
+		name string
+		tex  *Texture
+		want int
+	}{
+		{"map_Kd", m.DiffuseMap, 2},
+		{"map_Bump", m.BumpMap, 3},
+		{"map_Ks", m.SpecularMap, 4},
+	} {
+		if tt.tex == nil || tt.tex.Bounds().Dx() != tt.want {
+			t.Errorf("%s is %v, want the texture %d pixels wide", tt.name, tt.tex.Bounds(), tt.want)
+		}
+	}
+
+	// Materials that use the same file share its texture.
+	if materials["shared"].DiffuseMap != m.DiffuseMap {
+		t.Error("the same texture file was loaded twice")
+	}
+}
+
+func TestLoadMtlProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		mtl  string
+		// wantLine, wantDirective and wantReason describe the problem.
+		wantLine      int
+		wantDirective string
+		wantReason    string
+		// want is the material named "m" that a lenient loader loads, which
+		// starts with the defaults.
+		want func(m *Material)
+	}{
+		{
+			name:          "malformed color",
+			mtl:           "newmtl m\nKd 1 0 0\n\nKd 0 x 1",
+			wantLine:      4,
+			wantDirective: "Kd",
+			wantReason:    `invalid number "x"`,
+			want:          func(m *Material) { m.Diffuse = mgl64.Vec3{1, 0, 0} },
+		},
+		{
+			name:          "too few numbers",
+			mtl:           "newmtl m\nKs 1 1",
+			wantLine:      2,
+			wantDirective: "Ks",
+			wantReason:    "expected 3 numbers, got 2",
+			want:          func(m *Material) {},
+		},
+		{
+			// A malformed dissolve leaves the material opaque.
+			name:          "malformed dissolve",
+			mtl:           "newmtl m\n# opacity\nd half",
+			wantLine:      3,
+			wantDirective: "d",
+			wantReason:    `invalid number "half"`,
+			want:          func(m *Material) {},
+		},
+		{
+			name:          "malformed transparency",
+			mtl:           "newmtl m\nd 0.5\nTr 0.1 0.2",
+			wantLine:      3,
+			wantDirective: "Tr",
+			wantReason:    "expected 1 number, got 2",
+			want:          func(m *Material) { m.Dissolve = 0.5 },
+		},
+		{
+			name:          "missing texture file",
+			mtl:           "newmtl m\nmap_Kd",
+			wantLine:      2,
+			wantDirective: "map_Kd",
+			wantReason:    "missing texture file",
+			want:          func(m *Material) {},
+		},
+		{
+			name:          "no material",
+			mtl:           "Kd 1 0 0\nnewmtl m",
+			wantLine:      1,
+			wantDirective: "Kd",
+			wantReason:    "no material declared with newmtl",
+			want:          func(m *Material) {},
+		},
+	}
+
+	for _, tt := range tests {
+		want := &ParseError{
+			File:      "bad.mtl",
+			Line:      tt.wantLine,
+			Directive: tt.wantDirective,
+			Reason:    tt.wantReason,
+		}
+
+		t.Run(tt.name+"/strict", func(t *testing.T) {
+			l := &ObjLoader{Strict: true}
+			_, err := l.LoadMtlReader(strings.NewReader(tt.mtl), "bad.mtl")
+
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			checkParseError(t, perr, want)
+		})
+
+		t.Run(tt.name+"/lenient", func(t *testing.T) {
+			l := new(ObjLoader)
+			materials, err := l.LoadMtlReader(strings.NewReader(tt.mtl), "bad.mtl")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(l.Warnings) != 1 {
+				t.Fatalf("got %d warnings, want 1: %v", len(l.Warnings), l.Warnings)
+			}
+			checkParseError(t, l.Warnings[0], want)
+
+			wantMat := *newMaterial("m")
+			tt.want(&wantMat)
+			if got := materials["m"]; got == nil || *got != wantMat {
+				t.Errorf("got material %+v, want %+v", got, wantMat)
+			}
+		})
+	}
+}
+
+func TestObjMaterialColors(t *testing.T) {
+	const w, h = 32, 16
+
+	// A quad on the left has a material with a white diffuse texture that is
+	// tinted red, and a quad on the right has a green material without a
+	// texture. The model's texture is blue.
+	l := &ObjLoader{Open: memoryOpen(map[string]string{
+		"lib.mtl":   "newmtl red\nKd 1 0 0\nmap_Kd white.png\nnewmtl green\nKd 0 1 0\n",
+		"white.png": pngFile(t, 4, 4, color.White),
+	})}
+	const obj = `mtllib lib.mtl
+v -2 -1 0
+v 0 -1 0
+v 0 1 0
+v -2 1 0
+v 2 -1 0
+v 2 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 -1
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1
+usemtl green
+f 2/1/1 5/2/1 6/3/1 3/4/1
+`
+	blue := NewTexture(filledImage(4, 4, color.RGBA{0, 0, 0xFF, 0xFF}))
+	camera := NewCamera(mgl64.Vec3{0, 0, -2}, mgl64.DegToRad(90), float64(w)/h)
+	m, err := l.LoadReader(strings.NewReader(obj), "quads.obj", blue, w, h, camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, _ := RenderImage(m, camera, w, h)
+
+	// The camera looks down +z, so the left quad is on the right of the
+	// image.
+	tests := []struct {
+		name string
+		x    int
+		want color.RGBA
+	}{
+		{"textured material", w/2 + 4, color.RGBA{0xFF, 0, 0, 0xFF}},
+		{"untextured material", w/2 - 4, color.RGBA{0, 0xFF, 0, 0xFF}},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, h/2); got != tt.want {
+			t.Errorf("%s drawn as %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,9 @@
 package view
 
 import (
-	"image"
+	"math"
 
+	"github.com/damienfamed75/pine/tdraw"
 	"github.com/go-gl/mathgl/mgl64"
 	"github.com/oakmound/oak/render"
 )
@@ -13,74 +14,467 @@ type Model struct {
 	// a render.Sprite has a position and a buffer of image data which
 	// it uses to draw to the screen at that position.
 	*render.Sprite
-	// the textureData is the local texture file (.bmp in the original, .png in this version)
+	// the texture is the image (.bmp in the original, .png in this version)
 	// that is referred to to color each triangle face
-	textureData *image.RGBA
+	texture *Texture
+	// color is the flat color of faces that don't have a texture or material.
+	color mgl64.Vec3
+	// opacity scales the alpha of every pixel of the model.
+	opacity float64
+
+	// materials are all the materials declared by the model's mtllib files.
+	materials map[string]*Material
+	// faceMaterials holds the material of every triangle in the model.
+	// A nil material means the triangle is drawn with texture and lit with
+	// material.
+	faceMaterials []*Material
+	// material lights the faces that don't have a material of their own.
+	material *Material
 
 	outVertices []mgl64.Vec3
 	outUVs      []mgl64.Vec3
 	outNormals  []mgl64.Vec3
+	// outColors are the colors of each vertex, which are only used when the
+	// model has vertex colors and the face doesn't have a texture.
+	outColors []mgl64.Vec3
+
+	// frame is the frame buffer the model is drawn into, which is kept
+	// between frames. It's created the first time the model is drawn.
+	frame *tdraw.FrameBuffer
+	// w and h are the size that the model is drawn at.
+	w, h int
+
+	// shader decides how the model is drawn. A nil shader uses
+	// DefaultShader.
+	shader Shader
 
-	// quat represents the model's rotation.
-	// the quaternion isn't directly applied to the transform, but instead
-	// applied to the camera's viewing position and angles to create
-	// the illusion of full 3D rotation.
-	quat mgl64.Quat
+	// options controls how the model is drawn. A nil options uses
+	// DefaultRenderOptions.
+	options *RenderOptions
+	// overrides are the options set with the model's setters, which take
+	// priority over options.
+	overrides renderOverrides
 
-	scale    mgl64.Mat4
-	position mgl64.Mat4
-	// transform represents the model's position and scale.
-	// transform mgl64.Mat4
-	angle float64
+	// The model's transform is built from its position, rotation and scale,
+	// which are applied to its vertices in the order scale, rotation and
+	// then position.
+	position mgl64.Vec3
+	rotation mgl64.Quat
+	scale    mgl64.Vec3
+
+	// lights are the lights in world space that light the model when it's
+	// drawn on its own rather than as part of a scene.
+	lights []*tdraw.Light
+
+	// node is the node the model is attached to, if any, which the model's
+	// transform is relative to.
+	node *Node
 
 	camera *Camera
 }
 
-// SetRotation resets the rotation of the model to what is provided.
+// newModel creates an empty model with an assigned width and height and no
+// rotation, scale or offset, ready to be filled in by a loader.
+func newModel(w, h int, camera *Camera) *Model {
+	return &Model{
+		materials: make(map[string]*Material),
+		color:     mgl64.Vec3{1, 1, 1},
+		opacity:   1,
+		material:  newBaseMaterial(),
+		w:         w,
+		h:         h,
+		// Empty sprite that has an assigned width and height.
+		Sprite: render.NewEmptySprite(0, 0, w, h),
+		// Enough data to render the object.
+		camera:   camera,
+		rotation: mgl64.QuatIdent(),
+		scale:    mgl64.Vec3{1, 1, 1},
+	}
+}
+
+// GetTexture returns the texture used by faces that don't have a material.
+func (m *Model) GetTexture() *Texture {
+	return m.texture
+}
+
+// SetTexture replaces the texture used by faces that don't have a material.
+// The texture may be shared with other models.
+func (m *Model) SetTexture(t *Texture) {
+	m.texture = t
+}
+
+// GetColor returns the flat color that faces without a texture or material
+// are drawn with.
+func (m *Model) GetColor() mgl64.Vec3 {
+	return m.color
+}
+
+// SetColor sets the flat color that faces without a texture or material are
+// drawn with, which is white by default. The red, green and blue components
+// range from 0 to 1.
+func (m *Model) SetColor(rgb mgl64.Vec3) {
+	m.color = rgb
+}
+
+// GetOpacity returns how opaque the model is, from 0 to 1.
+func (m *Model) GetOpacity() float64 {
+	return m.opacity
+}
+
+// SetOpacity sets how opaque the model is, from 0 for invisible to 1 for as
+// opaque as its textures and materials are, which is the default. The model
+// can only be seen through when it's drawn with tdraw.AlphaBlend, refer to
+// SetAlphaMode.
+func (m *Model) SetOpacity(opacity float64) {
+	m.opacity = mgl64.Clamp(opacity, 0, 1)
+}
+
+// GetShader returns the shader the model is drawn with.
+func (m *Model) GetShader() Shader {
+	if m.shader == nil {
+		return DefaultShader
+	}
+	return m.shader
+}
+
+// SetShader sets the shader the model is drawn with. A nil shader makes the
+// model go back to using DefaultShader.
+func (m *Model) SetShader(s Shader) {
+	m.shader = s
+}
+
+// GetRenderOptions returns the options the model is drawn with, which are
+// its own options or DefaultRenderOptions, along with any options set with
+// SetCullMode, SetInterpolation, SetShading and SetAlphaMode.
+func (m *Model) GetRenderOptions() RenderOptions {
+	o := DefaultRenderOptions
+	if m.options != nil {
+		o = *m.options
+	}
+	return m.overrides.apply(o)
+}
+
+// SetRenderOptions gives the model its own options to be drawn with. A nil
+// options makes the model go back to using DefaultRenderOptions. Options set
+// with the model's setters, such as SetCullMode, are replaced too.
+func (m *Model) SetRenderOptions(options *RenderOptions) {
+	if options != nil {
+		// Copy the options so changing them afterwards doesn't affect the
+		// model.
+		o := *options
+		options = &o
+	}
+	m.options = options
+	m.overrides = renderOverrides{}
+}
+
+// AddLight adds lights in world space that light the model when it's drawn
+// on its own. Models in a scene are lit by the scene's lights instead. A
+// model without lights is lit by a white light shining from the camera.
+func (m *Model) AddLight(lights ...*tdraw.Light) {
+	m.lights = append(m.lights, lights...)
+}
+
+// RemoveLight removes a light from the model.
+func (m *Model) RemoveLight(l *tdraw.Light) {
+	for i, light := range m.lights {
+		if light == l {
+			m.lights = append(m.lights[:i], m.lights[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetLights returns the lights that light the model when it's drawn on its
+// own.
+func (m *Model) GetLights() []*tdraw.Light {
+	return m.lights
+}
+
+// GetFrameBuffer returns the frame buffer that the model is drawn into, or
+// nil if the model hasn't been drawn yet.
+func (m *Model) GetFrameBuffer() *tdraw.FrameBuffer {
+	return m.frame
+}
+
+// SetFrameBuffer sets the frame buffer that the model is drawn into. Models
+// that are drawn one after another can share a frame buffer to save memory,
+// since each model resizes and clears it before drawing. A nil frame buffer
+// gives the model a new one of its own the next time it's drawn.
+//
+// To draw several models together with the same depth buffer, use
+// RenderFrame instead.
+func (m *Model) SetFrameBuffer(fb *tdraw.FrameBuffer) {
+	m.frame = fb
+}
+
+// GetCullMode returns which faces of the model are skipped when drawing.
+func (m *Model) GetCullMode() tdraw.CullMode {
+	return m.GetRenderOptions().Cull
+}
+
+// SetCullMode sets which faces of the model are skipped when drawing. Models
+// draw every face by default. Closed models, whose back faces are always
+// hidden, draw faster with tdraw.CullBack.
+func (m *Model) SetCullMode(cull tdraw.CullMode) {
+	m.overrides.cull = &cull
+}
+
+// GetInterpolation returns how the model's texture coordinates, normals and
+// colors are blended across its faces.
+func (m *Model) GetInterpolation() tdraw.Interpolation {
+	return m.GetRenderOptions().Interpolation
+}
+
+// SetInterpolation sets how the model's texture coordinates, normals and
+// colors are blended across its faces. Models are perspective correct by
+// default, while tdraw.Affine gives textures the wobble of older consoles.
+func (m *Model) SetInterpolation(interp tdraw.Interpolation) {
+	m.overrides.interpolation = &interp
+}
+
+// GetShading returns how the model's faces are lit.
+func (m *Model) GetShading() tdraw.Shading {
+	return m.GetRenderOptions().Shading
+}
+
+// SetShading sets how the model's faces are lit. Models are lit per pixel by
+// default, while tdraw.FlatShading gives them a faceted low poly look and
+// tdraw.GouraudShading lights their vertices, the way older games did.
+func (m *Model) SetShading(shading tdraw.Shading) {
+	m.overrides.shading = &shading
+}
+
+// GetAlphaMode returns what the alpha of the model's pixels is used for.
+func (m *Model) GetAlphaMode() tdraw.AlphaMode {
+	return m.GetRenderOptions().Alpha
+}
+
+// SetAlphaMode sets what the alpha of the model's pixels is used for. Models
+// are opaque by default, while tdraw.AlphaTest cuts out the parts of their
+// textures that are mostly transparent and tdraw.AlphaBlend lets them be seen
+// through, such as glass or a model that is fading away.
+func (m *Model) SetAlphaMode(alpha tdraw.AlphaMode) {
+	m.overrides.alpha = &alpha
+}
+
+// GetMaterial returns the material with the given name, or nil if the model
+// doesn't have a material by that name.
+func (m *Model) GetMaterial(name string) *Material {
+	return m.materials[name]
+}
+
+// GetBaseMaterial returns the material that lights the faces which don't have
+// a material of their own.
+func (m *Model) GetBaseMaterial() *Material {
+	return m.material
+}
+
+// SetBaseMaterial sets the material that lights the faces which don't have a
+// material of their own. The faces keep the model's texture, vertex colors or
+// flat color, but are lit with the material's ambient, specular and emissive
+// colors and its shininess. A nil material goes back to the default, which
+// has no highlights.
+func (m *Model) SetBaseMaterial(mat *Material) {
+	if mat == nil {
+		mat = newBaseMaterial()
+	}
+	m.material = mat
+}
+
+// NumVertices returns how many vertices the model has. Every three vertices
+// in a row make up a triangle, so vertex i belongs to face i/3.
+func (m *Model) NumVertices() int {
+	return len(m.outVertices)
+}
+
+// GetVertexPosition returns the position of vertex i in model space.
+func (m *Model) GetVertexPosition(i int) mgl64.Vec3 {
+	return m.outVertices[i]
+}
+
+// GetVertexNormal returns the normal of vertex i in model space.
+func (m *Model) GetVertexNormal(i int) mgl64.Vec3 {
+	return m.outNormals[i]
+}
+
+// GetVertexUV returns the texture coordinate of vertex i, which is zero when
+// the model's file doesn't give the vertex one.
+func (m *Model) GetVertexUV(i int) mgl64.Vec3 {
+	return m.outUVs[i]
+}
+
+// GetVertexColor returns the color of vertex i, and false when the model
+// doesn't have vertex colors.
+func (m *Model) GetVertexColor(i int) (mgl64.Vec3, bool) {
+	if m.outColors == nil {
+		return mgl64.Vec3{}, false
+	}
+	return m.outColors[i], true
+}
+
+// GetFaceMaterial returns the material of the given face, or nil if the face
+// is drawn with the model's texture, vertex colors or flat color and lit with
+// its base material.
+func (m *Model) GetFaceMaterial(face int) *Material {
+	return m.faceMaterials[face]
+}
+
+// GetRotation returns the model's rotation.
+func (m *Model) GetRotation() mgl64.Quat {
+	return m.rotation
+}
+
+// SetRotation resets the rotation of the model to a rotation of angle radians
+// around the axis.
 // If you wish to rotate based on the current rotation then please refer to
 // AddRotation instead.
 func (m *Model) SetRotation(angle float64, axis mgl64.Vec3) {
-	m.angle = angle
-	m.quat = mgl64.QuatRotate(angle, axis)
+	m.rotation = mgl64.QuatRotate(angle, axis.Normalize())
+}
+
+// SetRotationQuat resets the rotation of the model to the quaternion.
+func (m *Model) SetRotationQuat(q mgl64.Quat) {
+	m.rotation = q.Normalize()
 }
 
-// AddRotation adds to the existing rotation axis.
+// AddRotation rotates the model by angle radians around the axis on top of
+// its current rotation.
 func (m *Model) AddRotation(angle float64, axis mgl64.Vec3) {
-	m.angle += angle
-	m.quat = mgl64.QuatRotate(m.angle, axis)
+	m.Rotate(axis, angle)
+}
+
+// Rotate rotates the model by angle radians around the axis on top of its
+// current rotation. The axis is in the model's parent space, which is world
+// space unless the model is attached to a node, so rotating around the y axis
+// always spins the model around its parent's up direction no matter how it's
+// already rotated.
+func (m *Model) Rotate(axis mgl64.Vec3, angle float64) {
+	// The quaternion is normalized every time so that rounding errors don't
+	// build up and start to scale the model.
+	m.rotation = mgl64.QuatRotate(angle, axis.Normalize()).Mul(m.rotation).Normalize()
+}
+
+// SetRotationEuler resets the rotation of the model to rotations of x, y and
+// z radians around the x, y and z axis, which are applied in that order.
+func (m *Model) SetRotationEuler(x, y, z float64) {
+	m.rotation = mgl64.QuatRotate(z, mgl64.Vec3{0, 0, 1}).
+		Mul(mgl64.QuatRotate(y, mgl64.Vec3{0, 1, 0})).
+		Mul(mgl64.QuatRotate(x, mgl64.Vec3{1, 0, 0}))
+}
+
+// GetRotationEuler returns the model's rotation as rotations around the x, y
+// and z axis in radians, in the same order as SetRotationEuler uses.
+func (m *Model) GetRotationEuler() (x, y, z float64) {
+	r := m.rotation.Mat4()
+
+	// The rotation matrix is Rz * Ry * Rx, so the sine of the y rotation is
+	// the negated bottom left of the matrix.
+	sy := mgl64.Clamp(-r.At(2, 0), -1, 1)
+	y = math.Asin(sy)
+
+	// When the y rotation is straight up or down then the x and z rotations
+	// spin around the same axis, so the x rotation is left at zero.
+	if math.Abs(sy) > 1-1e-9 {
+		return 0, y, math.Atan2(-r.At(0, 1), r.At(1, 1))
+	}
+
+	return math.Atan2(r.At(2, 1), r.At(2, 2)), y, math.Atan2(r.At(1, 0), r.At(0, 0))
 }
 
-// GetTransform combines the scale and position to give the transform matrix of
-// this model.
+// LookAt rotates the model so that its front, which is the +z axis, faces
+// the target, with its top facing up as much as it can. The target and up
+// are in world space, so models attached to a node face the target no matter
+// how the node is moved or rotated.
+func (m *Model) LookAt(target, up mgl64.Vec3) {
+	// The model's position and rotation are relative to its node, so the
+	// target and up are moved into the node's space first.
+	if m.node != nil {
+		toNode := m.node.GetWorldTransform().Inv()
+		target = toNode.Mul4x1(target.Vec4(1)).Vec3()
+		up = toNode.Mul4x1(up.Vec4(0)).Vec3()
+	}
+
+	forward := target.Sub(m.position)
+	if forward.Len() == 0 {
+		return
+	}
+	forward = forward.Normalize()
+
+	right := up.Cross(forward)
+	if right.Len() == 0 {
+		// Up is parallel to forward, so any right angle will do.
+		right = mgl64.Vec3{1, 0, 0}.Cross(forward)
+		if right.Len() == 0 {
+			right = mgl64.Vec3{0, 0, 1}.Cross(forward)
+		}
+	}
+	right = right.Normalize()
+
+	// The rotation moves the x, y and z axis onto right, up and forward.
+	rot := mgl64.Mat4FromCols(
+		right.Vec4(0),
+		forward.Cross(right).Vec4(0),
+		forward.Vec4(0),
+		mgl64.Vec4{0, 0, 0, 1},
+	)
+	m.rotation = mgl64.Mat4ToQuat(rot).Normalize()
+}
+
+// GetTransform combines the position, rotation and scale to give the
+// transform matrix of this model. When the model is attached to a node the
+// transform is relative to the node, refer to GetWorldTransform.
 func (m *Model) GetTransform() mgl64.Mat4 {
-	return m.scale.Mul4(m.position)
+	return trsMatrix(m.position, m.rotation, m.scale)
+}
+
+// GetWorldTransform returns the transform matrix that moves the model's
+// vertices into world space, including the transforms of the node it's
+// attached to and all of the node's parents.
+func (m *Model) GetWorldTransform() mgl64.Mat4 {
+	if m.node == nil {
+		return m.GetTransform()
+	}
+	return m.node.GetWorldTransform().Mul4(m.GetTransform())
+}
+
+// GetNode returns the node the model is attached to, or nil if it isn't
+// attached to one. Models are attached with Node.Attach.
+func (m *Model) GetNode() *Node {
+	return m.node
 }
 
 // GetScale gets the model's scale on its x, y, and z axis.
 func (m *Model) GetScale() mgl64.Vec3 {
-	return m.scale.Diag().Vec3()
+	return m.scale
 }
 
 // SetScale sets the model's relative scale on the x, y, and z axis
-// If you wish to scale based on thge current rotation then please refer to
+// If you wish to scale based on the current scale then please refer to
 // AddScale instead.
 func (m *Model) SetScale(x, y, z float64) {
-	m.scale = mgl64.Scale3D(x, y, z)
+	m.scale = mgl64.Vec3{x, y, z}
 }
 
 // AddScale scales the object relative to its current scale.
 func (m *Model) AddScale(x, y, z float64) {
-	m.scale = m.scale.Add(mgl64.Scale3D(x, y, z))
+	m.scale = m.scale.Add(mgl64.Vec3{x, y, z})
+}
+
+// GetPosition gets the model's position, which is relative to the node it's
+// attached to, if any.
+func (m *Model) GetPosition() mgl64.Vec3 {
+	return m.position
 }
 
 // SetPosition sets the position of the object from 0,0,0.
-// If you wish to set the scale based on its current position then please refer
-// to AddPosition instead.
+// If you wish to set the position based on its current position then please
+// refer to AddPosition instead.
 func (m *Model) SetPosition(x, y, z float64) {
-	m.position = mgl64.Translate3D(x, y, z)
+	m.position = mgl64.Vec3{x, y, z}
 }
 
 // AddPosition sets the model's position relative to its current position.
 func (m *Model) AddPosition(x, y, z float64) {
-	m.position = m.position.Add(mgl64.Translate3D(x, y, z))
+	m.position = m.position.Add(mgl64.Vec3{x, y, z})
 }
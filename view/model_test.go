@@ -0,0 +1,72 @@
+package view
+
+import (
+	"math"
+	"testing"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestModelRenderOptions(t *testing.T) {
+	defaults := DefaultRenderOptions
+	defer func() { DefaultRenderOptions = defaults }()
+
+	m := newModel(1, 1, nil)
+	m.SetCullMode(tdraw.CullBack)
+	m.SetShading(tdraw.FlatShading)
+
+	// Options that weren't set on the model keep following the defaults.
+	DefaultRenderOptions.Workers = 3
+	DefaultRenderOptions.Interpolation = tdraw.Affine
+
+	want := RenderOptions{
+		Workers:       3,
+		Cull:          tdraw.CullBack,
+		Interpolation: tdraw.Affine,
+		Shading:       tdraw.FlatShading,
+	}
+	if got := m.GetRenderOptions(); got != want {
+		t.Errorf("got options %+v, want %+v", got, want)
+	}
+
+	// The setters override the model's own options too.
+	m.SetRenderOptions(&RenderOptions{Workers: 2})
+	m.SetAlphaMode(tdraw.AlphaBlend)
+
+	want = RenderOptions{Workers: 2, Alpha: tdraw.AlphaBlend}
+	if got := m.GetRenderOptions(); got != want {
+		t.Errorf("got options %+v, want %+v", got, want)
+	}
+
+	// Going back to the defaults forgets everything that was set.
+	m.SetRenderOptions(nil)
+	if got := m.GetRenderOptions(); got != DefaultRenderOptions {
+		t.Errorf("got options %+v, want the defaults %+v", got, DefaultRenderOptions)
+	}
+}
+
+func TestModelLookAtOnNode(t *testing.T) {
+	node := NewNode("turret")
+	node.SetPosition(5, 0, 0)
+	node.SetRotation(math.Pi/2, mgl64.Vec3{0, 1, 0})
+
+	m := newModel(1, 1, nil)
+	m.SetPosition(0, 1, 0)
+	node.Attach(m)
+
+	target := mgl64.Vec3{5, 1, -10}
+	m.LookAt(target, mgl64.Vec3{0, 1, 0})
+
+	world := m.GetWorldTransform()
+	pos := world.Mul4x1(mgl64.Vec4{0, 0, 0, 1}).Vec3()
+	front := world.Mul4x1(mgl64.Vec4{0, 0, 1, 0}).Vec3()
+	top := world.Mul4x1(mgl64.Vec4{0, 1, 0, 0}).Vec3()
+
+	if want := target.Sub(pos).Normalize(); !front.ApproxEqualThreshold(want, 1e-9) {
+		t.Errorf("model faces %v in world space, want %v", front, want)
+	}
+	if want := (mgl64.Vec3{0, 1, 0}); !top.ApproxEqualThreshold(want, 1e-9) {
+		t.Errorf("model's top faces %v in world space, want %v", top, want)
+	}
+}
@@ -0,0 +1,215 @@
+package view
+
+import (
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// Node is a point in a hierarchy of transforms. Every node has a transform
+// that is relative to its parent, so moving, rotating or scaling a node
+// does the same to all of its children and the models attached to them,
+// such as a turret on a tank or a weapon in a hand.
+type Node struct {
+	// Name is used to tell nodes apart and isn't used while drawing.
+	Name string
+
+	parent   *Node
+	children []*Node
+	models   []*Model
+
+	// The node's local transform, relative to its parent.
+	position mgl64.Vec3
+	rotation mgl64.Quat
+	scale    mgl64.Vec3
+
+	// world is the node's transform in world space, which is only worked
+	// out again when the node or one of its parents has changed. When a
+	// node is dirty then so are all of its children.
+	world mgl64.Mat4
+	dirty bool
+}
+
+// NewNode creates a node with no parent at the origin that isn't rotated or
+// scaled.
+func NewNode(name string) *Node {
+	return &Node{
+		Name:     name,
+		rotation: mgl64.QuatIdent(),
+		scale:    mgl64.Vec3{1, 1, 1},
+		dirty:    true,
+	}
+}
+
+// GetParent returns the node's parent, or nil if it doesn't have one.
+func (n *Node) GetParent() *Node {
+	return n.parent
+}
+
+// GetChildren returns the node's children.
+func (n *Node) GetChildren() []*Node {
+	return n.children
+}
+
+// AddChild makes the node the parent of child, taking it from its old
+// parent if it had one. Adding one of the node's own parents panics, since
+// the hierarchy can't have loops.
+func (n *Node) AddChild(child *Node) {
+	for p := n; p != nil; p = p.parent {
+		if p == child {
+			panic("view: node can't be a child of itself")
+		}
+	}
+
+	if child.parent != nil {
+		child.parent.RemoveChild(child)
+	}
+	child.parent = n
+	n.children = append(n.children, child)
+	child.markDirty()
+}
+
+// RemoveChild removes child from the node's children, leaving it without a
+// parent.
+func (n *Node) RemoveChild(child *Node) {
+	for i, c := range n.children {
+		if c == child {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			child.parent = nil
+			child.markDirty()
+			return
+		}
+	}
+}
+
+// Attach attaches models to the node, so they're moved along with it. A
+// model can only be attached to one node at a time, so it's detached from
+// any node it was attached to before.
+func (n *Node) Attach(models ...*Model) {
+	for _, m := range models {
+		if m.node != nil {
+			m.node.Detach(m)
+		}
+		m.node = n
+		n.models = append(n.models, m)
+	}
+}
+
+// Detach detaches a model from the node.
+func (n *Node) Detach(m *Model) {
+	for i, mod := range n.models {
+		if mod == m {
+			n.models = append(n.models[:i], n.models[i+1:]...)
+			m.node = nil
+			return
+		}
+	}
+}
+
+// GetModels returns the models attached to the node, not including the ones
+// attached to its children.
+func (n *Node) GetModels() []*Model {
+	return n.models
+}
+
+// Walk calls fn for the node and then every node below it, parents before
+// their children.
+func (n *Node) Walk(fn func(*Node)) {
+	fn(n)
+	for _, c := range n.children {
+		c.Walk(fn)
+	}
+}
+
+// GetPosition gets the node's position relative to its parent.
+func (n *Node) GetPosition() mgl64.Vec3 {
+	return n.position
+}
+
+// SetPosition sets the node's position relative to its parent.
+func (n *Node) SetPosition(x, y, z float64) {
+	n.position = mgl64.Vec3{x, y, z}
+	n.markDirty()
+}
+
+// AddPosition moves the node relative to its current position.
+func (n *Node) AddPosition(x, y, z float64) {
+	n.position = n.position.Add(mgl64.Vec3{x, y, z})
+	n.markDirty()
+}
+
+// GetRotation returns the node's rotation relative to its parent.
+func (n *Node) GetRotation() mgl64.Quat {
+	return n.rotation
+}
+
+// SetRotation resets the rotation of the node to a rotation of angle radians
+// around the axis.
+func (n *Node) SetRotation(angle float64, axis mgl64.Vec3) {
+	n.rotation = mgl64.QuatRotate(angle, axis.Normalize())
+	n.markDirty()
+}
+
+// SetRotationQuat resets the rotation of the node to the quaternion.
+func (n *Node) SetRotationQuat(q mgl64.Quat) {
+	n.rotation = q.Normalize()
+	n.markDirty()
+}
+
+// Rotate rotates the node by angle radians around the axis on top of its
+// current rotation. The axis is in the space of the node's parent.
+func (n *Node) Rotate(axis mgl64.Vec3, angle float64) {
+	n.rotation = mgl64.QuatRotate(angle, axis.Normalize()).Mul(n.rotation).Normalize()
+	n.markDirty()
+}
+
+// GetScale gets the node's scale on its x, y, and z axis.
+func (n *Node) GetScale() mgl64.Vec3 {
+	return n.scale
+}
+
+// SetScale sets the node's scale on the x, y, and z axis.
+func (n *Node) SetScale(x, y, z float64) {
+	n.scale = mgl64.Vec3{x, y, z}
+	n.markDirty()
+}
+
+// GetTransform returns the node's transform relative to its parent.
+func (n *Node) GetTransform() mgl64.Mat4 {
+	return trsMatrix(n.position, n.rotation, n.scale)
+}
+
+// GetWorldTransform returns the node's transform in world space, which
+// includes the transforms of all of its parents.
+func (n *Node) GetWorldTransform() mgl64.Mat4 {
+	if n.dirty {
+		n.world = n.GetTransform()
+		if n.parent != nil {
+			n.world = n.parent.GetWorldTransform().Mul4(n.world)
+		}
+		n.dirty = false
+	}
+
+	return n.world
+}
+
+// markDirty marks the node and all of its children as needing their world
+// transforms worked out again.
+func (n *Node) markDirty() {
+	// Children of a dirty node are already dirty, so there's no need to go
+	// any further.
+	if n.dirty {
+		return
+	}
+
+	n.dirty = true
+	for _, c := range n.children {
+		c.markDirty()
+	}
+}
+
+// trsMatrix builds a transform matrix that scales, then rotates and then
+// moves to the position.
+func trsMatrix(position mgl64.Vec3, rotation mgl64.Quat, scale mgl64.Vec3) mgl64.Mat4 {
+	return mgl64.Translate3D(position.Elem()).
+		Mul4(rotation.Mat4()).
+		Mul4(mgl64.Scale3D(scale.Elem()))
+}
@@ -0,0 +1,136 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// worldPosition returns where the origin of a node ends up in world space.
+func worldPosition(n *Node) mgl64.Vec3 {
+	return n.GetWorldTransform().Col(3).Vec3()
+}
+
+func TestNodeWorldTransform(t *testing.T) {
+	root, arm, hand := NewNode("root"), NewNode("arm"), NewNode("hand")
+	root.AddChild(arm)
+	arm.AddChild(hand)
+
+	root.SetPosition(1, 0, 0)
+	arm.SetPosition(0, 2, 0)
+	hand.SetPosition(0, 0, 3)
+
+	if got, want := worldPosition(hand), (mgl64.Vec3{1, 2, 3}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Fatalf("got hand at %v, want %v", got, want)
+	}
+
+	// Every node's world transform has been worked out, so changing the
+	// root must mark the nodes below it as changed too.
+	root.SetPosition(-1, 0, 0)
+	if got, want := worldPosition(hand), (mgl64.Vec3{-1, 2, 3}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Errorf("got hand at %v after moving the root, want %v", got, want)
+	}
+
+	// Turning the arm a quarter turn around the y axis swings the hand from
+	// +z to +x, but leaves the root where it is.
+	arm.Rotate(mgl64.Vec3{0, 1, 0}, mgl64.DegToRad(90))
+	if got, want := worldPosition(hand), (mgl64.Vec3{2, 2, 0}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Errorf("got hand at %v after turning the arm, want %v", got, want)
+	}
+	if got, want := worldPosition(root), (mgl64.Vec3{-1, 0, 0}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Errorf("got root at %v after turning the arm, want %v", got, want)
+	}
+
+	// Scaling the root scales the distances below it.
+	root.SetScale(2, 2, 2)
+	if got, want := worldPosition(hand), (mgl64.Vec3{5, 4, 0}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Errorf("got hand at %v after scaling the root, want %v", got, want)
+	}
+
+	// Models attached to a node follow it.
+	m := newModel(1, 1, nil)
+	m.SetPosition(1, 0, 0)
+	hand.Attach(m)
+	if got, want := m.GetWorldTransform().Col(3).Vec3(), (mgl64.Vec3{5, 4, -2}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Errorf("got model at %v, want %v", got, want)
+	}
+}
+
+func TestNodeAddChildReparents(t *testing.T) {
+	a, b, child := NewNode("a"), NewNode("b"), NewNode("child")
+	a.SetPosition(1, 0, 0)
+	b.SetPosition(0, 1, 0)
+
+	a.AddChild(child)
+	if got, want := worldPosition(child), (mgl64.Vec3{1, 0, 0}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Fatalf("got child at %v, want %v", got, want)
+	}
+
+	b.AddChild(child)
+	if child.GetParent() != b {
+		t.Errorf("got parent %v, want b", child.GetParent())
+	}
+	if len(a.GetChildren()) != 0 {
+		t.Errorf("the old parent still has the children %v", a.GetChildren())
+	}
+	if got := b.GetChildren(); len(got) != 1 || got[0] != child {
+		t.Errorf("got children %v, want only the child", got)
+	}
+	if got, want := worldPosition(child), (mgl64.Vec3{0, 1, 0}); !got.ApproxEqualThreshold(want, 1e-6) {
+		t.Errorf("got child at %v after moving it to b, want %v", got, want)
+	}
+
+	b.RemoveChild(child)
+	if child.GetParent() != nil || len(b.GetChildren()) != 0 {
+		t.Errorf("got parent %v and children %v, want the child removed", child.GetParent(), b.GetChildren())
+	}
+	if got := worldPosition(child); !got.ApproxEqualThreshold(mgl64.Vec3{}, 1e-6) {
+		t.Errorf("got child at %v after removing it, want the origin", got)
+	}
+}
+
+func TestNodeAddChildCycle(t *testing.T) {
+	root, mid, leaf := NewNode("root"), NewNode("mid"), NewNode("leaf")
+	root.AddChild(mid)
+	mid.AddChild(leaf)
+
+	tests := []struct {
+		name          string
+		parent, child *Node
+	}{
+		{"itself", mid, mid},
+		{"its parent", leaf, mid},
+		{"its grandparent", leaf, root},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("adding the child didn't panic")
+				}
+
+				// The hierarchy is left as it was.
+				if root.GetParent() != nil || mid.GetParent() != root || leaf.GetParent() != mid {
+					t.Error("the hierarchy was changed")
+				}
+			}()
+
+			tt.parent.AddChild(tt.child)
+		})
+	}
+}
+
+func TestNodeWalk(t *testing.T) {
+	root, a, b, c := NewNode("root"), NewNode("a"), NewNode("b"), NewNode("c")
+	root.AddChild(a)
+	root.AddChild(c)
+	a.AddChild(b)
+
+	var got string
+	root.Walk(func(n *Node) { got += n.Name + " " })
+
+	if want := "root a b c "; got != want {
+		t.Errorf("walked %q, want %q", got, want)
+	}
+}
@@ -3,150 +3,436 @@ package view
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/disintegration/gift"
 	"github.com/go-gl/mathgl/mgl64"
-	"github.com/oakmound/oak/render"
-	"github.com/oakmound/oak/render/mod"
 )
 
-// LoadObj loads a .obj file into memory, loading all its information
+// ObjLoader loads .obj files and the .mtl files they reference.
+// The zero value is a lenient loader.
+type ObjLoader struct {
+	// Strict makes the loader fail on the first malformed line it finds
+	// instead of skipping over the line.
+	Strict bool
+
+	// Warnings holds every problem that a lenient loader skipped over.
+	Warnings []*ParseError
+
+	// Open opens the files referenced by a model, such as material libraries
+	// and textures. When Open is nil files are opened from the OS.
+	Open OpenFunc
+
+	// TextureFilters are run over every texture the loader loads, for
+	// example gift.Mean(5, true) to soften the textures. Textures aren't
+	// filtered when there aren't any filters.
+	TextureFilters []gift.Filter
+
+	// textures caches the textures that have been loaded by file name.
+	textures map[string]*Texture
+}
+
+// faceVertex holds the indices of a single vertex of a face.
+// The indices are zero based and uv and normal are -1 when the face doesn't
+// reference a texture coordinate or normal.
+type faceVertex struct {
+	vertex, uv, normal int
+}
+
+// LoadObj loads a .obj file into memory with a lenient ObjLoader.
+// Malformed lines are skipped, refer to ObjLoader for more control over how
+// problems in the file are handled.
+func LoadObj(objFile, texFile string, w, h int, camera *Camera) (*Model, error) {
+	return new(ObjLoader).Load(objFile, texFile, w, h, camera)
+}
+
+// LoadObjReader reads a .obj file from r with a lenient ObjLoader.
+// Refer to ObjLoader.LoadReader for more information.
+func LoadObjReader(r io.Reader, name string, tex *Texture, w, h int, camera *Camera) (*Model, error) {
+	return new(ObjLoader).LoadReader(r, name, tex, w, h, camera)
+}
+
+// Load loads a .obj file into memory, loading all its information
 // including the texture for the .obj file.
 //
 // v - vertices
 // vn - vertex normalized
 // vt - vertex texture coordinate
-// f - faces (triangles)
-// mtl files are for another time for now.
-// f 1/13/4 51/13/5 2/42/26
-//				  3rd coord
-//        2nd coord
-// 1st coord
-func LoadObj(objFile, texFile string, w, h int, camera *Camera) (*Model, error) {
-	fobj, err := os.Open(objFile)
+// f - faces (polygons, which are split into triangles)
+// mtllib - material library (.mtl) relative to the .obj file
+// usemtl - material used by the faces that follow
+//
+//	f 1/13/4 51/13/5 2/42/26
+//	                 3rd coord
+//	         2nd coord
+//	  1st coord
+//
+// Face vertices may also be written as v, v/vt or v//vn, and negative indices
+// count backwards from the most recently declared element. Vertices without a
+// normal get one calculated from the surrounding faces, and vertices without
+// a texture coordinate use 0,0.
+//
+// texFile is the texture used by faces that don't have a material, and is
+// loaded relative to the directory of the .obj file the same way material
+// libraries are. It may be left empty if every face has a material.
+//
+// Problems with the contents of the file are returned as a *ParseError.
+func (l *ObjLoader) Load(objFile, texFile string, w, h int, camera *Camera) (*Model, error) {
+	fobj, err := l.Open.open(objFile)
 	if err != nil {
 		return nil, err
 	}
 	defer fobj.Close()
 
-	tex, err := render.LoadSprite("model", texFile)
-	if err != nil {
-		return nil, err
+	var tex *Texture
+	if texFile != "" {
+		tex, err = l.loadTexture(l.Open.dir(objFile), texFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Soften the texture.
-	mod.GiftFilter(
-		// ksize must be an odd number.
-		gift.Mean(5, true),
-	)(tex.GetRGBA())
-	
-
-	mod := &Model{
-		// Raw texture data from pixel to pixel.
-		textureData: tex.GetRGBA(),
-		// Empty sprite that has an assigned width and height.
-		Sprite: render.NewEmptySprite(0, 0, w, h),
-		// Enough data to render the object.
-		camera: camera,
-		// quat: mgl64.QuatRotate(mgl64.DegToRad(0), mgl64.Vec3{0, 1, 0}),
-		scale:    mgl64.Scale3D(1, 1, 1),
-		position: mgl64.Translate3D(0, 0, 0),
-	}
-
-	// quat := mgl64.QuatIdent().Rotate(mgl64.Vec3{
-	// 	0, mgl64.DegToRad(45), 0,
-	// })
-	// mod.transform = mgl64.Translate3D(0, 0, 0).
-	// 	Mul4(quat.Mat4()).
-	// 	Mul4(mgl64.Scale3D(1, 1, 1))
-	// mod.transform = mgl64.Translate3D(0, 0, -2).
-	// 	Mul4(mgl64.HomogRotate3D(mgl64.DegToRad(45), mgl64.Vec3{0, 1, 0})).
-	// 	Mul4(mgl64.Scale3D(1, 1, 1))
+	return l.load(fobj, objFile, tex, w, h, camera)
+}
+
+// LoadReader reads a .obj file from r, the same way Load loads a file.
+// name is used in errors and to find the files the model references, which
+// are opened relative to its directory. tex is the texture used by faces
+// that don't have a material, and may be nil.
+func (l *ObjLoader) LoadReader(r io.Reader, name string, tex *Texture, w, h int, camera *Camera) (*Model, error) {
+	return l.load(r, name, tex, w, h, camera)
+}
+
+// load parses the .obj file read from r into a new model.
+func (l *ObjLoader) load(r io.Reader, objFile string, tex *Texture, w, h int, camera *Camera) (*Model, error) {
+	mod := newModel(w, h, camera)
+	// Texture used by faces without a material.
+	mod.texture = tex
 
 	var (
-		uvIndices     []uint
-		vertexIndices []uint
-		normalIndices []uint
+		uvIndices     []int
+		vertexIndices []int
+		normalIndices []int
 
 		tmpUVs      []mgl64.Vec3
 		tmpVertices []mgl64.Vec3
 		tmpNormals  []mgl64.Vec3
+
+		// material is the material assigned by the last usemtl directive.
+		material *Material
+
+		lineNum int
 	)
 
-	scanner := bufio.NewScanner(fobj)
+	scanner := bufio.NewScanner(r)
 
 	for scanner.Scan() {
-		var (
-			vertex struct{ x, y, z float64 }
-		)
-
-		line := scanner.Text()
+		lineNum++
 
-		if len(line) < 2 {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
 			continue
 		}
-		if line[0] == 'v' && line[1] == 'n' {
-			// vertex normals.
-			fmt.Sscanf(line, "vn %f %f %f", &vertex.x, &vertex.y, &vertex.z)
-			tmpNormals = append(tmpNormals, mgl64.Vec3{
-				vertex.x, vertex.y, vertex.z,
+
+		// problem reports what is wrong with the current line.
+		problem := func(err error) error {
+			return l.report(&ParseError{
+				File:      objFile,
+				Line:      lineNum,
+				Directive: fields[0],
+				Reason:    err.Error(),
+				Err:       err,
 			})
-		} else if line[0] == 'v' && line[1] == 't' {
+		}
+
+		switch fields[0] {
+		case "v":
+			// vertices
+			// Some exporters append a w or an rgb color, which are ignored.
+			vertex, err := parseVec(fields[1:], 3, 7)
+			if err != nil {
+				if err := problem(err); err != nil {
+					return nil, err
+				}
+			}
+			// Malformed elements are still added so the indices of the
+			// elements that come after them aren't shifted.
+			tmpVertices = append(tmpVertices, vertex)
+		case "vt":
 			// vertex texture coordinates.
 			// Most of the time an obj file will not have a Z point, but we'll
 			// include it anyway in the case that an obj file actually uses it.
-			fmt.Sscanf(line, "vt %f %f %f", &vertex.x, &vertex.y, &vertex.z)
-			tmpUVs = append(tmpUVs, mgl64.Vec3{
-				vertex.x, vertex.y, vertex.z,
-			})
-		} else if line[0] == 'v' {
-			// vertices
-			fmt.Sscanf(line, "v %f %f %f", &vertex.x, &vertex.y, &vertex.z)
-			tmpVertices = append(tmpVertices, mgl64.Vec3{
-				vertex.x, vertex.y, vertex.z,
-			})
-		} else if line[0] == 'f' {
-			var (
-				uvIndex     [3]uint
-				vertexIndex [3]uint
-				normalIndex [3]uint
-			)
-
-			fmt.Sscanf(line, "f %d/%d/%d %d/%d/%d %d/%d/%d",
-				&vertexIndex[0], &uvIndex[0], &normalIndex[0],
-				&vertexIndex[1], &uvIndex[1], &normalIndex[1],
-				&vertexIndex[2], &uvIndex[2], &normalIndex[2],
-			)
-
-			uvIndices = append(uvIndices,
-				uvIndex[0], uvIndex[1], uvIndex[2])
-			vertexIndices = append(vertexIndices,
-				vertexIndex[0], vertexIndex[1], vertexIndex[2])
-			normalIndices = append(normalIndices,
-				normalIndex[0], normalIndex[1], normalIndex[2])
+			uv, err := parseVec(fields[1:], 1, 3)
+			if err != nil {
+				if err := problem(err); err != nil {
+					return nil, err
+				}
+			}
+			tmpUVs = append(tmpUVs, uv)
+		case "vn":
+			// vertex normals.
+			normal, err := parseVec(fields[1:], 3, 3)
+			if err != nil {
+				if err := problem(err); err != nil {
+					return nil, err
+				}
+			}
+			tmpNormals = append(tmpNormals, normal)
+		case "mtllib":
+			// material libraries are relative to the obj file.
+			for _, name := range fields[1:] {
+				materials, err := l.LoadMtl(l.Open.join(l.Open.dir(objFile), name))
+				if os.IsNotExist(err) {
+					// Exporters often reference libraries that weren't shipped
+					// with the model, in which case the texture file is used.
+					if err := l.report(&ParseError{
+						File:      objFile,
+						Line:      lineNum,
+						Directive: fields[0],
+						Reason:    fmt.Sprintf("material library %q not found", name),
+						Err:       err,
+					}); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				for matName, m := range materials {
+					mod.materials[matName] = m
+				}
+			}
+		case "usemtl":
+			// unknown materials leave the faces with the texture file.
+			name := strings.Join(fields[1:], " ")
+			material = mod.materials[name]
+			if material == nil {
+				if err := problem(fmt.Errorf("unknown material %q", name)); err != nil {
+					return nil, err
+				}
+			}
+		case "f":
+			// Faces may have any number of vertices, so each vertex is parsed
+			// on its own and the polygon is split into triangles after.
+			corners, err := parseFace(fields[1:],
+				len(tmpVertices), len(tmpUVs), len(tmpNormals))
+			if err != nil {
+				// Broken faces are skipped entirely.
+				if err := problem(err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			polygon := make([]mgl64.Vec3, len(corners))
+			for i, corner := range corners {
+				polygon[i] = tmpVertices[corner.vertex]
+			}
+
+			for _, tri := range triangulate(polygon) {
+				for _, i := range tri {
+					uvIndices = append(uvIndices, corners[i].uv)
+					vertexIndices = append(vertexIndices, corners[i].vertex)
+					normalIndices = append(normalIndices, corners[i].normal)
+				}
+				mod.faceMaterials = append(mod.faceMaterials, material)
+			}
+		case "o", "g", "s", "vp", "l", "p":
+			// objects, groups, smoothing groups, parameter space vertices,
+			// lines and points don't affect how the model is drawn.
+		default:
+			if err := problem(fmt.Errorf("unknown directive")); err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		// The line after the last one that was read couldn't be read.
+		return nil, &ParseError{File: objFile, Line: lineNum + 1, Reason: err.Error(), Err: err}
+	}
+
 	// Looping through the faces and getting their according vertices.
-	for i := range vertexIndices {
-		vertIdx := vertexIndices[i]
-		// The -1 is because OBJ files for arrays start at 1 not 0.
-		// So to compensate for Golang we are subtracting the index by one.
-		mod.outVertices = append(mod.outVertices, tmpVertices[vertIdx-1])
+	for _, idx := range vertexIndices {
+		mod.outVertices = append(mod.outVertices, tmpVertices[idx])
 	}
-	for i := range uvIndices {
-		vertIdx := uvIndices[i]
-		// The -1 is because OBJ files for arrays start at 1 not 0.
-		// So to compensate for Golang we are subtracting the index by one.
-		mod.outUVs = append(mod.outUVs, tmpUVs[vertIdx-1])
+	for _, idx := range uvIndices {
+		if idx < 0 {
+			mod.outUVs = append(mod.outUVs, mgl64.Vec3{})
+			continue
+		}
+		mod.outUVs = append(mod.outUVs, tmpUVs[idx])
 	}
-	for i := range normalIndices {
-		vertIdx := normalIndices[i]
-		// The -1 is because OBJ files for arrays start at 1 not 0.
-		// So to compensate for Golang we are subtracting the index by one.
-		mod.outNormals = append(mod.outNormals, tmpNormals[vertIdx-1])
+
+	// Smooth normals are only calculated when a face is missing its normals.
+	var smoothNormals []mgl64.Vec3
+	for i, idx := range normalIndices {
+		if idx < 0 {
+			if smoothNormals == nil {
+				smoothNormals = vertexNormals(tmpVertices, vertexIndices)
+			}
+			mod.outNormals = append(mod.outNormals, smoothNormals[vertexIndices[i]])
+			continue
+		}
+		mod.outNormals = append(mod.outNormals, tmpNormals[idx])
 	}
 
 	return mod, nil
 }
+
+// report handles a problem found on a line of a file. Strict loaders fail
+// with the problem, while lenient loaders record it as a warning and return
+// nil so that loading can carry on.
+func (l *ObjLoader) report(problem *ParseError) error {
+	if l.Strict {
+		return problem
+	}
+
+	l.Warnings = append(l.Warnings, problem)
+	return nil
+}
+
+// parseVec parses between min and max numbers into a vector. Only the first
+// three numbers are kept and any missing numbers are left at 0.
+func parseVec(fields []string, min, max int) (mgl64.Vec3, error) {
+	var vec mgl64.Vec3
+
+	if len(fields) < min || len(fields) > max {
+		if min == max {
+			return vec, fmt.Errorf("expected %d numbers, got %d", min, len(fields))
+		}
+		return vec, fmt.Errorf("expected %d to %d numbers, got %d", min, max, len(fields))
+	}
+
+	for i, field := range fields {
+		f, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return vec, fmt.Errorf("invalid number %q", field)
+		}
+		if i < len(vec) {
+			vec[i] = f
+		}
+	}
+
+	return vec, nil
+}
+
+// parseFace parses every vertex of a face.
+func parseFace(fields []string, numVertices, numUVs, numNormals int) ([]faceVertex, error) {
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("a face needs at least 3 vertices, got %d", len(fields))
+	}
+
+	corners := make([]faceVertex, len(fields))
+	for i, field := range fields {
+		corner, err := parseFaceVertex(field, numVertices, numUVs, numNormals)
+		if err != nil {
+			return nil, err
+		}
+		corners[i] = corner
+	}
+
+	return corners, nil
+}
+
+// parseFaceVertex parses a single vertex of a face in any of the forms
+// v, v/vt, v//vn or v/vt/vn. The amount of vertices, texture coordinates and
+// normals that have been declared so far are used to resolve negative
+// indices, which are relative to the end of each list.
+func parseFaceVertex(field string, numVertices, numUVs, numNormals int) (faceVertex, error) {
+	corner := faceVertex{uv: -1, normal: -1}
+	parts := strings.Split(field, "/")
+
+	if len(parts) > 3 || parts[0] == "" {
+		return corner, fmt.Errorf("invalid face vertex %q", field)
+	}
+
+	var err error
+	if corner.vertex, err = parseIndex(parts[0], numVertices, "vertex"); err != nil {
+		return corner, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if corner.uv, err = parseIndex(parts[1], numUVs, "texture coordinate"); err != nil {
+			return corner, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if corner.normal, err = parseIndex(parts[2], numNormals, "normal"); err != nil {
+			return corner, err
+		}
+	}
+
+	return corner, nil
+}
+
+// parseIndex converts a one based (or negative and relative) obj index into a
+// zero based index, making sure that it refers to one of the count elements
+// that have been declared.
+func parseIndex(s string, count int, element string) (int, error) {
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s index %q", element, s)
+	}
+
+	// The -1 is because OBJ files for arrays start at 1 not 0.
+	// So to compensate for Golang we are subtracting the index by one.
+	resolved := idx - 1
+	if idx < 0 {
+		// -1 refers to the last element that was declared.
+		resolved = count + idx
+	}
+	if idx == 0 || resolved < 0 || resolved >= count {
+		return 0, fmt.Errorf("%s index %d out of range, %d declared", element, idx, count)
+	}
+
+	return resolved, nil
+}
+
+// vertexNormals calculates a smooth normal for every vertex by averaging the
+// normals of all the triangles that use the vertex. Larger triangles have a
+// larger influence on the normal.
+func vertexNormals(vertices []mgl64.Vec3, indices []int) []mgl64.Vec3 {
+	normals := make([]mgl64.Vec3, len(vertices))
+	for i := 0; i+2 < len(indices); i += 3 {
+		a, b, c := vertices[indices[i]], vertices[indices[i+1]], vertices[indices[i+2]]
+		// The cross product's length is twice the triangle's area.
+		n := b.Sub(a).Cross(c.Sub(a))
+		for _, idx := range indices[i : i+3] {
+			normals[idx] = normals[idx].Add(n)
+		}
+	}
+
+	for i, n := range normals {
+		if l := n.Len(); l > 0 {
+			normals[i] = n.Mul(1 / l)
+		}
+	}
+
+	return normals
+}
+
+// loadTexture loads an image file and runs the loader's texture filters over
+// it. Every file is only loaded once by a loader, so models and materials
+// that use the same file share a single texture.
+func (l *ObjLoader) loadTexture(dir, file string) (*Texture, error) {
+	name := l.Open.join(dir, file)
+	if tex, ok := l.textures[name]; ok {
+		return tex, nil
+	}
+
+	tex, err := l.Open.loadTexture(dir, file, l.TextureFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.textures == nil {
+		l.textures = make(map[string]*Texture)
+	}
+	l.textures[name] = tex
+
+	return tex, nil
+}
@@ -0,0 +1,367 @@
+package view
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestParseFaceVertex(t *testing.T) {
+	// Every test has 4 vertices, 3 texture coordinates and 2 normals
+	// declared.
+	tests := []struct {
+		field   string
+		want    faceVertex
+		wantErr string
+	}{
+		{field: "1", want: faceVertex{vertex: 0, uv: -1, normal: -1}},
+		{field: "4", want: faceVertex{vertex: 3, uv: -1, normal: -1}},
+		{field: "2/3", want: faceVertex{vertex: 1, uv: 2, normal: -1}},
+		{field: "3//2", want: faceVertex{vertex: 2, uv: -1, normal: 1}},
+		{field: "1/2/1", want: faceVertex{vertex: 0, uv: 1, normal: 0}},
+		{field: "2/3/", want: faceVertex{vertex: 1, uv: 2, normal: -1}},
+
+		// Negative indices count back from the last declared element.
+		{field: "-1", want: faceVertex{vertex: 3, uv: -1, normal: -1}},
+		{field: "-4/-3", want: faceVertex{vertex: 0, uv: 0, normal: -1}},
+		{field: "-2//-1", want: faceVertex{vertex: 2, uv: -1, normal: 1}},
+		{field: "-1/-1/-2", want: faceVertex{vertex: 3, uv: 2, normal: 0}},
+
+		{field: "0", wantErr: "vertex index 0 out of range, 4 declared"},
+		{field: "5", wantErr: "vertex index 5 out of range, 4 declared"},
+		{field: "-5", wantErr: "vertex index -5 out of range, 4 declared"},
+		{field: "1/4", wantErr: "texture coordinate index 4 out of range, 3 declared"},
+		{field: "1//3", wantErr: "normal index 3 out of range, 2 declared"},
+		{field: "1/2/-3", wantErr: "normal index -3 out of range, 2 declared"},
+		{field: "x", wantErr: `invalid vertex index "x"`},
+		{field: "1/y", wantErr: `invalid texture coordinate index "y"`},
+		{field: "/1", wantErr: `invalid face vertex "/1"`},
+		{field: "1/2/3/4", wantErr: `invalid face vertex "1/2/3/4"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := parseFaceVertex(tt.field, 4, 3, 2)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadObjFaceForms(t *testing.T) {
+	const header = `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 0 1
+vn 0 0 1
+vn 0 0 -1
+`
+	var (
+		positions = []mgl64.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+		uvs       = []mgl64.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+		none      = []mgl64.Vec3{{}, {}, {}}
+		front     = []mgl64.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+		back      = []mgl64.Vec3{{0, 0, -1}, {0, 0, -1}, {0, 0, -1}}
+	)
+
+	tests := []struct {
+		name        string
+		face        string
+		wantUVs     []mgl64.Vec3
+		wantNormals []mgl64.Vec3
+	}{
+		// Vertices without normals get smooth normals from the faces, which
+		// point towards +z for this triangle.
+		{"v", "f 1 2 3", none, front},
+		{"v/vt", "f 1/1 2/2 3/3", uvs, front},
+		{"v//vn", "f 1//2 2//2 3//2", none, back},
+		{"v/vt/vn", "f 1/1/2 2/2/2 3/3/2", uvs, back},
+		{"negative", "f -3/-3/-1 -2/-2/-1 -1/-1/-1", uvs, back},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := new(ObjLoader)
+			m, err := l.LoadReader(strings.NewReader(header+tt.face), "tri.obj", nil, 1, 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(l.Warnings) > 0 {
+				t.Fatalf("got warnings %v", l.Warnings)
+			}
+
+			checkVecs(t, "vertices", m.outVertices, positions)
+			checkVecs(t, "texture coordinates", m.outUVs, tt.wantUVs)
+			checkVecs(t, "normals", m.outNormals, tt.wantNormals)
+		})
+	}
+}
+
+func TestLoadObjNegativeIndicesAreRelative(t *testing.T) {
+	// Negative indices refer to the elements declared before the face, not
+	// to the end of the file.
+	const obj = `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+f -3 -2 -1
+v 5 0 0
+v 6 0 0
+v 5 1 0
+f -3 -2 -1
+`
+	m, err := LoadObjReader(strings.NewReader(obj), "tris.obj", nil, 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkVecs(t, "vertices", m.outVertices, []mgl64.Vec3{
+		{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+		{5, 0, 0}, {6, 0, 0}, {5, 1, 0},
+	})
+}
+
+// checkVecs reports an error when got and want aren't the same vectors.
+func checkVecs(t *testing.T, what string, got, want []mgl64.Vec3) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("got %d %s, want %d", len(got), what, len(want))
+		return
+	}
+	for i := range got {
+		if !got[i].ApproxEqual(want[i]) {
+			t.Errorf("%s[%d] = %v, want %v", what, i, got[i], want[i])
+		}
+	}
+}
+
+func TestObjLoaderProblems(t *testing.T) {
+	// Every file starts with a triangle on lines 1 to 4, which is still
+	// loaded by lenient loaders.
+	const triangle = "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"
+
+	tests := []struct {
+		name string
+		// lines are added after the triangle, starting at line 5.
+		lines []string
+		// wantLine, wantDirective and wantReason describe the problem.
+		wantLine      int
+		wantDirective string
+		wantReason    string
+		// wantCause is the error that caused the problem, if it matters.
+		wantCause error
+		// wantTriangles is the number of triangles a lenient loader loads.
+		wantTriangles int
+	}{
+		{
+			name:          "vertex index out of range",
+			lines:         []string{"f 1 2 3", "f 1 2 9"},
+			wantLine:      6,
+			wantDirective: "f",
+			wantReason:    "vertex index 9 out of range, 3 declared",
+			wantTriangles: 2,
+		},
+		{
+			name:          "normal index out of range",
+			lines:         []string{"vn 0 0 1", "f 1//1 2//1 3//2"},
+			wantLine:      6,
+			wantDirective: "f",
+			wantReason:    "normal index 2 out of range, 1 declared",
+			wantTriangles: 1,
+		},
+		{
+			name:          "too few face vertices",
+			lines:         []string{"f 1 2"},
+			wantLine:      5,
+			wantDirective: "f",
+			wantReason:    "a face needs at least 3 vertices, got 2",
+			wantTriangles: 1,
+		},
+		{
+			// The broken vertex is still counted, so the face after it uses
+			// the vertex it meant to.
+			name:          "malformed vertex",
+			lines:         []string{"v 1 x 0", "f 1 2 4"},
+			wantLine:      5,
+			wantDirective: "v",
+			wantReason:    `invalid number "x"`,
+			wantTriangles: 2,
+		},
+		{
+			name:          "unknown directive",
+			lines:         []string{"", "# comment", "bogus 1 2"},
+			wantLine:      7,
+			wantDirective: "bogus",
+			wantReason:    "unknown directive",
+			wantTriangles: 1,
+		},
+		{
+			name:          "unknown material",
+			lines:         []string{"usemtl nope", "f 3 2 1"},
+			wantLine:      5,
+			wantDirective: "usemtl",
+			wantReason:    `unknown material "nope"`,
+			wantTriangles: 2,
+		},
+		{
+			name:          "missing material library",
+			lines:         []string{"mtllib gone.mtl"},
+			wantLine:      5,
+			wantDirective: "mtllib",
+			wantReason:    `material library "gone.mtl" not found`,
+			wantCause:     os.ErrNotExist,
+			wantTriangles: 1,
+		},
+	}
+
+	// Files are opened from memory, so the material library never exists.
+	open := func(name string) (io.ReadCloser, error) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	for _, tt := range tests {
+		obj := triangle + strings.Join(tt.lines, "\n")
+		want := &ParseError{
+			File:      "bad.obj",
+			Line:      tt.wantLine,
+			Directive: tt.wantDirective,
+			Reason:    tt.wantReason,
+		}
+
+		t.Run(tt.name+"/strict", func(t *testing.T) {
+			l := &ObjLoader{Strict: true, Open: open}
+			_, err := l.LoadReader(strings.NewReader(obj), "bad.obj", nil, 1, 1, nil)
+
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			checkParseError(t, perr, want)
+			if tt.wantCause != nil && !errors.Is(err, tt.wantCause) {
+				t.Errorf("got error %v, want one caused by %v", err, tt.wantCause)
+			}
+			if got, wantMsg := err.Error(), fmt.Sprintf("bad.obj:%d: %s: %s", tt.wantLine, tt.wantDirective, tt.wantReason); got != wantMsg {
+				t.Errorf("got message %q, want %q", got, wantMsg)
+			}
+			if len(l.Warnings) > 0 {
+				t.Errorf("strict loader recorded warnings %v", l.Warnings)
+			}
+		})
+
+		t.Run(tt.name+"/lenient", func(t *testing.T) {
+			l := &ObjLoader{Open: open}
+			m, err := l.LoadReader(strings.NewReader(obj), "bad.obj", nil, 1, 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(l.Warnings) != 1 {
+				t.Fatalf("got %d warnings, want 1: %v", len(l.Warnings), l.Warnings)
+			}
+			checkParseError(t, l.Warnings[0], want)
+			if got := len(m.outVertices) / 3; got != tt.wantTriangles {
+				t.Errorf("got %d triangles, want %d", got, tt.wantTriangles)
+			}
+		})
+	}
+}
+
+// checkParseError reports an error when got doesn't describe the same problem
+// as want, ignoring the error that caused it. got must have a cause though.
+func checkParseError(t *testing.T, got, want *ParseError) {
+	t.Helper()
+
+	if got.Err == nil {
+		t.Errorf("got %+v without the error that caused it", got)
+	}
+	g := *got
+	g.Err = nil
+	if g != *want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestObjLoaderUnreadableLine(t *testing.T) {
+	// The scanner can't read lines longer than its buffer, which even a
+	// lenient loader can't skip over.
+	obj := "v 0 0 0\n# " + strings.Repeat("x", bufio.MaxScanTokenSize) + "\nv 1 0 0\n"
+
+	_, err := LoadObjReader(strings.NewReader(obj), "long.obj", nil, 1, 1, nil)
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want a *ParseError", err)
+	}
+	if perr.Line != 2 || !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("got %+v, want line 2 caused by bufio.ErrTooLong", perr)
+	}
+	if want := "long.obj:2: " + bufio.ErrTooLong.Error(); err.Error() != want {
+		t.Errorf("got message %q, want %q", err.Error(), want)
+	}
+}
+
+// memoryOpen returns an OpenFunc that opens the files from memory.
+func memoryOpen(files map[string]string) OpenFunc {
+	return func(name string) (io.ReadCloser, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return ioutil.NopCloser(strings.NewReader(data)), nil
+	}
+}
+
+func TestObjLoaderOpen(t *testing.T) {
+	l := &ObjLoader{Open: memoryOpen(map[string]string{
+		"models/quad.obj": "mtllib quad.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n",
+		"models/quad.mtl": "newmtl m\nmap_Kd skins/quad.png\n",
+		// The model's texture is next to the model, like its materials.
+		"models/skin.png":       pngFile(t, 2, 2, color.White),
+		"models/skins/quad.png": pngFile(t, 3, 3, color.White),
+	})}
+
+	m, err := l.Load("models/quad.obj", "skin.png", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Warnings) > 0 {
+		t.Fatalf("got warnings %v", l.Warnings)
+	}
+
+	if tex := m.GetTexture(); tex == nil || tex.Bounds().Dx() != 2 {
+		t.Errorf("got texture %v, want models/skin.png", tex.Bounds())
+	}
+	if mat := m.GetMaterial("m"); mat == nil || mat.DiffuseMap.Bounds().Dx() != 3 {
+		t.Errorf("got material %+v, want one textured with models/skins/quad.png", mat)
+	}
+
+	if _, err := l.Load("models/quad.obj", "missing.png", 1, 1, nil); !os.IsNotExist(err) {
+		t.Errorf("got error %v for a missing texture, want one for which os.IsNotExist is true", err)
+	}
+	if _, err := l.Load("models/missing.obj", "", 1, 1, nil); !os.IsNotExist(err) {
+		t.Errorf("got error %v for a missing model, want one for which os.IsNotExist is true", err)
+	}
+}
@@ -1,13 +1,10 @@
 package view
 
 import (
-	"image"
 	"image/draw"
-	"math"
 	"sync"
 
 	"github.com/damienfamed75/pine/tdraw"
-	"github.com/go-gl/mathgl/mgl64"
 )
 
 // Draw calls DrawOffset at 0 offset.
@@ -18,95 +15,122 @@ func (m *Model) Draw(buff draw.Image) {
 // DrawOffset will simply render the model and then offset it on the window
 // by the provided x and y offsets.
 func (m *Model) DrawOffset(buff draw.Image, xOff, yOff float64) {
-	// Get the boundaries of the model's sprite.
-	// This should be the width and height assigned.
-	bounds := m.Sprite.GetRGBA().Bounds()
-	// Reset the sprite's RGBA values.
-	m.Sprite.SetRGBA(image.NewRGBA(bounds))
-
-	// Setup a zbuffer so we know what pixels we should draw and which ones
-	// are behind others we have already drawn. Initialize all values in the
-	// buffer to be as far back as possible (-math.MaxFloat64)
-	zbuff := make([][]float64, bounds.Max.X)
-	for i := range zbuff {
-		zbuff[i] = make([]float64, bounds.Max.Y)
-		for j := range zbuff[i] {
-			zbuff[i][j] = -math.MaxFloat64
-		}
+	if m.frame == nil {
+		m.frame = tdraw.NewFrameBuffer(m.w, m.h)
 	}
 
-	// Rotation gets applied to the camera items to emulate that the camera
-	// is viewing the object from a different angle.
-	eye := m.quat.Rotate(m.camera.GetPosition())            // position.
-	up := m.quat.Rotate(m.camera.GetUpRotation())           // upward rotation.
-	forward := m.quat.Rotate(m.camera.GetForwardRotation()) // forward rotation.
+	// The frame buffer is reused every frame, but it may be shared with
+	// other models of a different size.
+	m.frame.Resize(m.w, m.h)
+	m.frame.Clear()
+
+	m.render(m.frame, m.camera, m.lights)
+
+	// Let oak render the buffer onto the window. The sprite is pointed at
+	// the frame buffer every time since resizing replaces its image.
+	m.Sprite.SetRGBA(m.frame.Color)
+	m.Sprite.DrawOffset(buff, xOff, yOff)
+}
+
+// render draws the model as seen from the given camera into the frame buffer,
+// lit by the lights in world space. Blended faces are drawn after the rest of
+// the model from back to front, but are only mixed with what is already in
+// the frame buffer.
+func (m *Model) render(fb *tdraw.FrameBuffer, camera *Camera, lights []*tdraw.Light) {
+	workers := m.GetRenderOptions().workers()
+
+	opaque, blended := m.primitives(fb.Bounds().Dx(), fb.Bounds().Dy(), camera, lights)
+	tdraw.DrawTiled(fb, opaque, workers)
 
-	// Get the camera's projection matrix.
-	// This allows us to create perspective when drawing the object.
-	proj := m.camera.GetViewProjection()
+	tdraw.SortBackToFront(blended)
+	tdraw.DrawTiled(fb, blended, workers)
+}
+
+// primitives returns the primitives of the model as seen from the given
+// camera on a buffer of the given width and height, lit by the lights in
+// world space. The primitives of the faces that are blended, which are every
+// face of a model drawn with tdraw.AlphaBlend and the faces with a material
+// that can be seen through, are returned apart from the opaque ones. Both are
+// in the same order as the model's triangles.
+func (m *Model) primitives(w, h int, camera *Camera, lights []*tdraw.Light) (opaque, blended []tdraw.Primitive) {
+	// The camera's transform moves vertices from world space into eye space,
+	// which is combined with the model's transform to get the model view
+	// matrix.
+	view := camera.GetTransform()
+	modelView := view.Mul4(m.GetWorldTransform())
+
+	// Lights are moved into eye space too, where the faces are lit.
+	var eyeLights []tdraw.Light
+	for _, l := range lights {
+		eyeLights = append(eyeLights, l.Transform(view))
+	}
 
-	transform := m.GetTransform()
+	opts := m.GetRenderOptions()
+
+	// Everything the shader needs to draw the model this frame.
+	uniforms := &Uniforms{
+		Model:     m,
+		Camera:    camera,
+		View:      view,
+		ModelView: modelView,
+		// Get the camera's projection matrix.
+		// This allows us to create perspective when drawing the object.
+		Projection: camera.GetPerspective(),
+		// Normals are moved into eye space with the inverse transpose so
+		// that scaling the model doesn't skew them.
+		NormalMatrix: modelView.Mat3().Inv().Transpose(),
+		Lights:       eyeLights,
+		Shading:      opts.Shading,
+	}
 
-	// If I'm able to remove these values that'd be create.
-	//
-	// These are used to calculate some simple projections of the model's
-	// triangles.
-	z := eye.Sub(forward).Normalize()
-	x := up.Cross(z).Normalize()
-	y := z.Cross(x)
+	// The opacity is applied after the shader, so every shader fades the
+	// same way.
+	shader := m.GetShader().Bind(uniforms)
+	if m.opacity < 1 {
+		shader = &opacityShader{Shader: shader, opacity: m.opacity}
+	}
 
 	var (
-		wg      sync.WaitGroup
-		indices = make(chan int)
+		batchSize = opts.batchSize()
+		numBatch  = (len(m.outVertices)/3 + batchSize - 1) / batchSize
+
+		wg sync.WaitGroup
+		// The channel holds every batch, so queueing them never waits on
+		// the workers.
+		batches = make(chan int, numBatch)
 		pkg     = workerPackage{
-			outUVs:      m.outUVs,
-			outVertices: m.outVertices,
-			outNormals:  m.outNormals,
-			zbuff:       zbuff,
-			spriteRGBA:  m.Sprite.GetRGBA(),
-			textureData: m.textureData,
-			x:           x,
-			y:           y,
-			z:           z,
-			eye:         eye,
-			// Get the sprite's width and height.
-			spriteDimensions: &image.Point{X: bounds.Max.X, Y: bounds.Max.Y},
-			proj:             proj,
-			transform:        transform,
+			shader:        shader,
+			vertices:      len(m.outVertices),
+			state:         opts.drawState(),
+			faceMaterials: m.faceMaterials,
+			w:             w,
+			h:             h,
+			batchSize:     batchSize,
+			batches:       make([][]tdraw.Primitive, numBatch),
+			blendBatches:  make([][]tdraw.Primitive, numBatch),
 		}
 	)
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < opts.workers(); i++ {
 		wg.Add(1)
-		go drawingWorker(&pkg, indices, &wg)
+		go drawingWorker(&pkg, batches, &wg)
 	}
 
-	for i := 0; i < len(m.outVertices); i += 3 {
-		indices <- i
+	for b := range pkg.batches {
+		batches <- b
 	}
 
-	// Close the indices channel to signal that the workers may stop.
-	close(indices)
+	// Close the batches channel to signal that the workers may stop.
+	close(batches)
 
 	// Wait for all the workers to finish their work.
 	wg.Wait()
 
-	// Let oak render the buffer onto the window.
-	m.Sprite.DrawOffset(buff, xOff, yOff)
-}
-
-func getFaceVertices(mod *Model, indices []uint, tmpVerts, out []mgl64.Vec3) {
-	for i := range indices {
-		idx := indices[i]
-
-		out = append(out, tmpVerts[idx-1])
-	}
-}
-
-func mulQuatTriangle(t tdraw.Triangle, quat mgl64.Quat) tdraw.Triangle {
-	return tdraw.Triangle{
-		A: quat.Rotate(t.A),
-		B: quat.Rotate(t.B),
-		C: quat.Rotate(t.C),
+	// The primitives of every batch are kept in order, so overlapping faces
+	// at the same depth always come out the same.
+	for b := range pkg.batches {
+		opaque = append(opaque, pkg.batches[b]...)
+		blended = append(blended, pkg.blendBatches[b]...)
 	}
+	return opaque, blended
 }
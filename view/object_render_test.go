@@ -0,0 +1,90 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// overlappingTriangles returns a .ply file with n triangles of different
+// sizes and colors that are scattered in front of the camera of
+// TestRenderWorkers. The triangles are on a few planes, so many of them
+// overlap at the same depth and the image depends on the order they're drawn
+// in.
+func overlappingTriangles(n int) string {
+	rnd := rand.New(rand.NewSource(1))
+
+	var ply strings.Builder
+	fmt.Fprintf(&ply, "ply\nformat ascii 1.0\n"+
+		"element vertex %d\nproperty float x\nproperty float y\nproperty float z\n"+
+		"property uchar red\nproperty uchar green\nproperty uchar blue\n"+
+		"element face %d\nproperty list uchar int vertex_indices\nend_header\n", n*3, n)
+	for i := 0; i < n; i++ {
+		center := mgl64.Vec3{rnd.Float64()*4 - 2, rnd.Float64()*3 - 1.5, float64(rnd.Intn(3)) - 1}
+		r, g, b := rnd.Intn(256), rnd.Intn(256), rnd.Intn(256)
+		for j := 0; j < 3; j++ {
+			v := center.Add(mgl64.Vec3{rnd.Float64() - 0.5, rnd.Float64() - 0.5, 0})
+			fmt.Fprintf(&ply, "%f %f %f %d %d %d\n", v.X(), v.Y(), v.Z(), r, g, b)
+		}
+	}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&ply, "3 %d %d %d\n", i*3, i*3+1, i*3+2)
+	}
+
+	return ply.String()
+}
+
+// TestRenderWorkers checks that drawing with many workers gives exactly the
+// same image as drawing with one. Run it with -race to also check that the
+// workers don't share anything they shouldn't.
+func TestRenderWorkers(t *testing.T) {
+	const w, h = 160, 90
+
+	camera := NewCamera(mgl64.Vec3{0, 0, -3}, mgl64.DegToRad(90), float64(w)/h)
+	m, err := LoadPLYReader(strings.NewReader(overlappingTriangles(500)), "tris.ply", w, h, camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		alpha   tdraw.AlphaMode
+		opacity float64
+	}{
+		{name: "opaque", alpha: tdraw.AlphaOpaque, opacity: 1},
+		// Blended pixels depend on the order they're drawn in.
+		{name: "blended", alpha: tdraw.AlphaBlend, opacity: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.SetOpacity(tt.opacity)
+
+			render := func(workers int) []byte {
+				m.SetRenderOptions(&RenderOptions{
+					Workers: workers,
+					// Small batches split the model between every worker.
+					BatchSize: 8,
+					Alpha:     tt.alpha,
+				})
+				img, _ := RenderImage(m, camera, w, h)
+				return img.Pix
+			}
+
+			want := render(1)
+			if bytes.Count(want, []byte{0}) == len(want) {
+				t.Fatal("nothing was drawn")
+			}
+			for i := 0; i < 3; i++ {
+				if got := render(8); !bytes.Equal(got, want) {
+					t.Fatal("drawing with 8 workers gave a different image than drawing with 1")
+				}
+			}
+		})
+	}
+}
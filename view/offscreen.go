@@ -0,0 +1,31 @@
+package view
+
+import (
+	"image"
+
+	"github.com/damienfamed75/pine/tdraw"
+)
+
+// RenderImage renders the model as seen from the given camera into a new
+// w by h image without going through oak's draw stack, so no window is
+// required. This is useful for thumbnails, tests and anything else that
+// needs the raw pixels of a model.
+//
+// The depth buffer that was used while rasterizing is returned alongside the
+// image and is indexed as depth[y*w+x]. Pixels that weren't covered by the
+// model keep a depth of tdraw.FarDepth.
+func RenderImage(m *Model, camera *Camera, w, h int) (*image.RGBA, []float64) {
+	fb := tdraw.NewFrameBuffer(w, h)
+
+	RenderFrame(m, camera, fb)
+
+	return fb.Color, fb.Depth
+}
+
+// RenderFrame renders the model as seen from the given camera into the frame
+// buffer without clearing it first, lit by the model's lights. Rendering
+// several models into the same frame buffer draws them together, with closer
+// faces hiding the ones behind them no matter which model they belong to.
+func RenderFrame(m *Model, camera *Camera, fb *tdraw.FrameBuffer) {
+	m.render(fb, camera, m.lights)
+}
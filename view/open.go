@@ -0,0 +1,64 @@
+package view
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/disintegration/gift"
+)
+
+// OpenFunc opens the files referenced by a model, such as material
+// libraries, buffers and textures, which lets models be loaded from
+// archives, embedded data or memory. Referenced files are named relative to
+// the file that references them using slash separated paths, and an OpenFunc
+// should return an error for which os.IsNotExist is true when a file doesn't
+// exist.
+//
+// A nil OpenFunc opens files from the OS, and loads textures through oak's
+// sprite loader.
+type OpenFunc func(name string) (io.ReadCloser, error)
+
+// open opens a file through the OpenFunc, or from the OS when it's nil.
+func (o OpenFunc) open(name string) (io.ReadCloser, error) {
+	if o != nil {
+		return o(name)
+	}
+
+	return os.Open(name)
+}
+
+// dir returns the directory that the files referenced by name are in.
+func (o OpenFunc) dir(name string) string {
+	if o != nil {
+		return path.Dir(name)
+	}
+
+	return filepath.Dir(name)
+}
+
+// join joins a directory and the name of a file in it.
+func (o OpenFunc) join(dir, name string) string {
+	if o != nil {
+		return path.Join(dir, name)
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// loadTexture loads an image file and runs the filters over it. Textures are
+// loaded through oak's sprite loader when the OpenFunc is nil.
+func (o OpenFunc) loadTexture(dir, file string, filters []gift.Filter) (*Texture, error) {
+	if o == nil {
+		return LoadTexture(dir, file, filters...)
+	}
+
+	f, err := o(o.join(dir, file))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return DecodeTexture(f, filters...)
+}
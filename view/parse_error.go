@@ -0,0 +1,31 @@
+package view
+
+import (
+	"fmt"
+)
+
+// ParseError describes a problem found on a single line of a model or
+// material file.
+type ParseError struct {
+	File      string // File is the path of the file being parsed.
+	Line      int    // Line is the line number of the problem, starting at 1.
+	Directive string // Directive is the keyword that starts the line, if it could be read.
+	Reason    string // Reason explains what is wrong with the line.
+
+	// Err is the error that caused the problem, if there was one.
+	Err error
+}
+
+// Error returns the problem formatted as file:line: directive: reason, or
+// file:line: reason when there's no directive.
+func (e *ParseError) Error() string {
+	if e.Directive == "" {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", e.File, e.Line, e.Directive, e.Reason)
+}
+
+// Unwrap returns the error that caused the problem.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
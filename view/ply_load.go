@@ -0,0 +1,400 @@
+package view
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// plyElement is an element declared in the header of a .ply file, such as
+// the vertices or the faces.
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyProperty is a single property of an element. List properties have a
+// countType, which is the type of the list's length.
+type plyProperty struct {
+	name      string
+	typ       string
+	countType string
+}
+
+// plyTypeSizes is the size in bytes of every scalar type in a .ply file,
+// including the older aliases of the sized types.
+var plyTypeSizes = map[string]int{
+	"char": 1, "int8": 1,
+	"uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2,
+	"ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4,
+	"uint": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+// plyReader reads the scalar values in the body of a .ply file.
+type plyReader interface {
+	read(typ string) (float64, error)
+	// fits returns whether there is enough of the body left for n more
+	// values of the type, so that list counts can be checked before the
+	// lists are allocated.
+	fits(n int, typ string) bool
+}
+
+// plyASCIIReader reads the whitespace separated values of an ASCII file.
+type plyASCIIReader struct {
+	// data is the part of the body that hasn't been read yet.
+	data []byte
+}
+
+func (r *plyASCIIReader) read(typ string) (float64, error) {
+	r.data = bytes.TrimLeftFunc(r.data, unicode.IsSpace)
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	end := bytes.IndexFunc(r.data, unicode.IsSpace)
+	if end < 0 {
+		end = len(r.data)
+	}
+	word := string(r.data[:end])
+	r.data = r.data[end:]
+
+	return strconv.ParseFloat(word, 64)
+}
+
+func (r *plyASCIIReader) fits(n int, typ string) bool {
+	// Every value takes at least one byte and is followed by a separator,
+	// apart from the very last one.
+	return n <= (len(r.data)+1)/2
+}
+
+// plyBinaryReader reads the values of a binary file in the given byte order.
+type plyBinaryReader struct {
+	r     *bytes.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (r *plyBinaryReader) fits(n int, typ string) bool {
+	return n <= r.r.Len()/plyTypeSizes[typ]
+}
+
+func (r *plyBinaryReader) read(typ string) (float64, error) {
+	b := r.buf[:plyTypeSizes[typ]]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return 0, err
+	}
+
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(b)), nil
+	}
+}
+
+// PLYLoader loads ASCII and binary .ply files.
+// The zero value is ready to use.
+type PLYLoader struct {
+	// Open opens the model's file. When Open is nil files are opened from
+	// the OS.
+	Open OpenFunc
+}
+
+// LoadPLY loads an ASCII or binary .ply file into memory with a PLYLoader.
+func LoadPLY(file string, w, h int, camera *Camera) (*Model, error) {
+	return new(PLYLoader).Load(file, w, h, camera)
+}
+
+// LoadPLYReader reads a .ply file from r with a PLYLoader.
+// Refer to PLYLoader.LoadReader for more information.
+func LoadPLYReader(r io.Reader, name string, w, h int, camera *Camera) (*Model, error) {
+	return new(PLYLoader).LoadReader(r, name, w, h, camera)
+}
+
+// Load loads an ASCII or binary (little or big endian) .ply file into
+// memory. The vertex element's x, y and z properties are required, while
+// nx, ny and nz normals, red, green and blue colors and s and t (or u and v)
+// texture coordinates are used when they're there.
+//
+// Models with vertex colors are drawn by blending the colors of each face's
+// vertices. Polygon faces are split into triangles, and vertices without
+// normals get one calculated from the surrounding faces.
+func (l *PLYLoader) Load(file string, w, h int, camera *Camera) (*Model, error) {
+	f, err := l.Open.open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return l.LoadReader(f, file, w, h, camera)
+}
+
+// LoadReader reads a .ply file from r, the same way Load loads a file. name
+// is used in errors.
+func (l *PLYLoader) LoadReader(r io.Reader, name string, w, h int, camera *Camera) (*Model, error) {
+	br := bufio.NewReader(r)
+
+	format, elements, err := readPLYHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	// The body is read into memory so the readers know how much of it is
+	// left.
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	var body plyReader
+	switch format {
+	case "ascii":
+		body = &plyASCIIReader{data: data}
+	case "binary_little_endian":
+		body = &plyBinaryReader{r: bytes.NewReader(data), order: binary.LittleEndian}
+	case "binary_big_endian":
+		body = &plyBinaryReader{r: bytes.NewReader(data), order: binary.BigEndian}
+	default:
+		return nil, fmt.Errorf("%s: unknown format %q", name, format)
+	}
+
+	var (
+		vertices, normals, colors, uvs []mgl64.Vec3
+		faces                          [][]int
+	)
+
+	for _, elem := range elements {
+		// values holds the scalar properties of the current element by name.
+		values := make(map[string]float64, len(elem.properties))
+
+		for i := 0; i < elem.count; i++ {
+			var list []int
+
+			for _, prop := range elem.properties {
+				if prop.countType == "" {
+					if values[prop.name], err = body.read(prop.typ); err != nil {
+						return nil, fmt.Errorf("%s: %s %d: %s: %v", name, elem.name, i, prop.name, err)
+					}
+					continue
+				}
+
+				n, err := body.read(prop.countType)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s %d: %s: %v", name, elem.name, i, prop.name, err)
+				}
+				// A list can't be longer than the rest of the body, which
+				// keeps broken counts from allocating huge lists.
+				if n != math.Trunc(n) || n < 0 || n > math.MaxInt32 || !body.fits(int(n), prop.typ) {
+					return nil, fmt.Errorf("%s: %s %d: %s: invalid list count %v", name, elem.name, i, prop.name, n)
+				}
+				items := make([]int, int(n))
+				for j := range items {
+					v, err := body.read(prop.typ)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %s %d: %s: %v", name, elem.name, i, prop.name, err)
+					}
+					items[j] = int(v)
+				}
+				if prop.name == "vertex_indices" || prop.name == "vertex_index" {
+					list = items
+				}
+			}
+
+			switch elem.name {
+			case "vertex":
+				vertices = append(vertices, mgl64.Vec3{values["x"], values["y"], values["z"]})
+				if elem.has("nx") {
+					normals = append(normals, mgl64.Vec3{values["nx"], values["ny"], values["nz"]})
+				}
+				if elem.has("red") {
+					scale := plyColorScale(elem.propertyType("red"))
+					colors = append(colors, mgl64.Vec3{
+						values["red"], values["green"], values["blue"],
+					}.Mul(scale))
+				}
+				// Texture coordinates go by a few different names.
+				switch {
+				case elem.has("s"):
+					uvs = append(uvs, mgl64.Vec3{values["s"], values["t"], 0})
+				case elem.has("u"):
+					uvs = append(uvs, mgl64.Vec3{values["u"], values["v"], 0})
+				case elem.has("texture_u"):
+					uvs = append(uvs, mgl64.Vec3{values["texture_u"], values["texture_v"], 0})
+				}
+			case "face":
+				faces = append(faces, list)
+			}
+		}
+	}
+
+	mod := newModel(w, h, camera)
+
+	// indices holds the vertex indices of every triangle, which are used to
+	// calculate normals when the file doesn't have any.
+	var indices []int
+	for i, face := range faces {
+		polygon := make([]mgl64.Vec3, len(face))
+		for j, idx := range face {
+			if idx < 0 || idx >= len(vertices) {
+				return nil, fmt.Errorf("%s: face %d: vertex index %d out of range, %d vertices", name, i, idx, len(vertices))
+			}
+			polygon[j] = vertices[idx]
+		}
+
+		for _, tri := range triangulate(polygon) {
+			for _, j := range tri {
+				indices = append(indices, face[j])
+			}
+			mod.faceMaterials = append(mod.faceMaterials, nil)
+		}
+	}
+
+	if normals == nil {
+		normals = vertexNormals(vertices, indices)
+	}
+
+	for _, idx := range indices {
+		mod.outVertices = append(mod.outVertices, vertices[idx])
+		mod.outNormals = append(mod.outNormals, normals[idx])
+
+		var uv mgl64.Vec3
+		if uvs != nil {
+			uv = uvs[idx]
+		}
+		mod.outUVs = append(mod.outUVs, uv)
+
+		if colors != nil {
+			mod.outColors = append(mod.outColors, colors[idx])
+		}
+	}
+
+	return mod, nil
+}
+
+// readPLYHeader reads the header of a .ply file up to and including the
+// end_header line, returning the format of the body and the elements in it.
+func readPLYHeader(r *bufio.Reader) (string, []plyElement, error) {
+	var (
+		format   string
+		elements []plyElement
+	)
+
+	for lineNum := 1; ; lineNum++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", nil, fmt.Errorf("header: %v", err)
+		}
+
+		fields := strings.Fields(line)
+		if lineNum == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return "", nil, fmt.Errorf("not a ply file")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return "", nil, fmt.Errorf("header line %d: invalid format", lineNum)
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return "", nil, fmt.Errorf("header line %d: invalid element", lineNum)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return "", nil, fmt.Errorf("header line %d: invalid element count %q", lineNum, fields[2])
+			}
+			elements = append(elements, plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return "", nil, fmt.Errorf("header line %d: property before any element", lineNum)
+			}
+
+			var prop plyProperty
+			switch {
+			case len(fields) == 5 && fields[1] == "list":
+				prop = plyProperty{name: fields[4], typ: fields[3], countType: fields[2]}
+			case len(fields) == 3:
+				prop = plyProperty{name: fields[2], typ: fields[1]}
+			default:
+				return "", nil, fmt.Errorf("header line %d: invalid property", lineNum)
+			}
+			if plyTypeSizes[prop.typ] == 0 || (prop.countType != "" && plyTypeSizes[prop.countType] == 0) {
+				return "", nil, fmt.Errorf("header line %d: unknown property type", lineNum)
+			}
+
+			elem := &elements[len(elements)-1]
+			elem.properties = append(elem.properties, prop)
+		case "end_header":
+			if format == "" {
+				return "", nil, fmt.Errorf("header is missing the format")
+			}
+			return format, elements, nil
+		case "comment", "obj_info":
+		default:
+			return "", nil, fmt.Errorf("header line %d: unknown keyword %q", lineNum, fields[0])
+		}
+	}
+}
+
+// has returns whether the element has a property with the given name.
+func (e plyElement) has(name string) bool {
+	return e.propertyType(name) != ""
+}
+
+// propertyType returns the type of the named property, or an empty string if
+// the element doesn't have the property.
+func (e plyElement) propertyType(name string) string {
+	for _, prop := range e.properties {
+		if prop.name == name {
+			return prop.typ
+		}
+	}
+
+	return ""
+}
+
+// plyColorScale returns the scale that converts a color component of the
+// given type into the range of 0 to 1.
+func plyColorScale(typ string) float64 {
+	switch typ {
+	case "uchar", "uint8":
+		return 1.0 / 0xFF
+	case "ushort", "uint16":
+		return 1.0 / 0xFFFF
+	default:
+		return 1
+	}
+}
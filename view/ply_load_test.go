@@ -0,0 +1,163 @@
+package view
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+)
+
+// plyTriangle is an ASCII .ply file with a single colored triangle.
+const plyTriangle = `ply
+format ascii 1.0
+element vertex 3
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0 255 0 0
+1 0 0 0 255 0
+0 1 0 0 0 255
+3 0 1 2
+`
+
+func TestPLYLoaderOpen(t *testing.T) {
+	l := &PLYLoader{Open: memoryOpen(map[string]string{
+		"models/tri.ply": plyTriangle,
+	})}
+
+	m, err := l.Load("models/tri.ply", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.outVertices); got != 3 {
+		t.Errorf("got %d vertices, want 3", got)
+	}
+	if got := len(m.outColors); got != 3 {
+		t.Errorf("got %d colors, want 3", got)
+	}
+
+	if _, err := l.Load("models/missing.ply", 1, 1, nil); !os.IsNotExist(err) {
+		t.Errorf("got error %v for a missing file, want one for which os.IsNotExist is true", err)
+	}
+}
+
+func TestLoadPLYMalformed(t *testing.T) {
+	// binaryFace is a binary file with a triangle whose face list has the
+	// given count, followed by the triangle's three indices.
+	binaryFace := func(countType string, count []byte) string {
+		var buf bytes.Buffer
+		buf.WriteString("ply\nformat binary_little_endian 1.0\n" +
+			"element vertex 3\nproperty float x\nproperty float y\nproperty float z\n" +
+			"element face 1\nproperty list " + countType + " int vertex_indices\nend_header\n")
+		for _, f := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+			binary.Write(&buf, binary.LittleEndian, f)
+		}
+		buf.Write(count)
+		for _, i := range []int32{0, 1, 2} {
+			binary.Write(&buf, binary.LittleEndian, i)
+		}
+		return buf.String()
+	}
+	// asciiFace is plyTriangle with its face replaced.
+	asciiFace := func(face string) string {
+		return strings.Replace(plyTriangle, "3 0 1 2\n", face, 1)
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name:    "not a ply file",
+			src:     "solid\n" + plyTriangle,
+			wantErr: "not a ply file",
+		},
+		{
+			name:    "missing format",
+			src:     strings.Replace(plyTriangle, "format ascii 1.0\n", "", 1),
+			wantErr: "header is missing the format",
+		},
+		{
+			name:    "unknown format",
+			src:     strings.Replace(plyTriangle, "format ascii", "format binary_middle_endian", 1),
+			wantErr: `unknown format "binary_middle_endian"`,
+		},
+		{
+			name:    "negative element count",
+			src:     strings.Replace(plyTriangle, "element vertex 3", "element vertex -3", 1),
+			wantErr: `header line 3: invalid element count "-3"`,
+		},
+		{
+			name:    "property before any element",
+			src:     strings.Replace(plyTriangle, "element vertex 3\n", "", 1),
+			wantErr: "header line 3: property before any element",
+		},
+		{
+			name:    "unknown property type",
+			src:     strings.Replace(plyTriangle, "property float x", "property quad x", 1),
+			wantErr: "header line 4: unknown property type",
+		},
+		{
+			name:    "unknown keyword",
+			src:     strings.Replace(plyTriangle, "end_header", "bogus\nend_header", 1),
+			wantErr: `header line 12: unknown keyword "bogus"`,
+		},
+		{
+			name:    "truncated header",
+			src:     plyTriangle[:strings.Index(plyTriangle, "end_header")],
+			wantErr: "header: EOF",
+		},
+		{
+			name:    "negative list count",
+			src:     asciiFace("-3 0 1 2\n"),
+			wantErr: "face 0: vertex_indices: invalid list count -3",
+		},
+		{
+			name:    "fractional list count",
+			src:     asciiFace("2.5 0 1 2\n"),
+			wantErr: "face 0: vertex_indices: invalid list count 2.5",
+		},
+		{
+			name:    "list count longer than the file",
+			src:     asciiFace("100000000 0 1 2\n"),
+			wantErr: "face 0: vertex_indices: invalid list count 1e+08",
+		},
+		{
+			name:    "negative binary list count",
+			src:     binaryFace("char", []byte{0xFD}),
+			wantErr: "face 0: vertex_indices: invalid list count -3",
+		},
+		{
+			name:    "huge binary list count",
+			src:     binaryFace("uint", []byte{0xFF, 0xFF, 0xFF, 0xFF}),
+			wantErr: "face 0: vertex_indices: invalid list count 4.294967295e+09",
+		},
+		{
+			name:    "truncated list",
+			src:     asciiFace("4 0 1 2\n"),
+			wantErr: "face 0: vertex_indices: unexpected EOF",
+		},
+		{
+			name:    "vertex index out of range",
+			src:     asciiFace("3 0 1 3\n"),
+			wantErr: "face 0: vertex index 3 out of range, 3 vertices",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPLYReader(strings.NewReader(tt.src), "bad.ply", 1, 1, nil)
+			if want := "bad.ply: "; err == nil || !strings.HasPrefix(err.Error(), want) || !strings.HasSuffix(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, want+"..."+tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package view
+
+import (
+	"runtime"
+
+	"github.com/damienfamed75/pine/tdraw"
+)
+
+// DefaultBatchSize is the number of triangles that a worker turns into
+// primitives at a time when RenderOptions doesn't set a batch size.
+const DefaultBatchSize = 256
+
+// DefaultRenderOptions are the options used by every model that hasn't been
+// given its own with SetRenderOptions. Changing them affects all of those
+// models the next time they're drawn.
+//
+// By default every face is drawn opaque with perspective correct
+// interpolation and lit per pixel, by one worker for each CPU. Closed models
+// can skip their hidden faces by opting into tdraw.CullBack.
+var DefaultRenderOptions = RenderOptions{
+	Cull: tdraw.CullNone,
+}
+
+// RenderOptions controls how a model is drawn.
+type RenderOptions struct {
+	// Workers is the number of goroutines that transform and draw the
+	// model's triangles. Zero uses runtime.GOMAXPROCS.
+	Workers int
+	// BatchSize is the number of triangles handed to a worker at a time.
+	// Zero uses DefaultBatchSize.
+	BatchSize int
+	// Cull decides which faces are skipped based on the way they're facing.
+	Cull tdraw.CullMode
+	// Interpolation decides how texture coordinates, normals and colors are
+	// blended across faces.
+	Interpolation tdraw.Interpolation
+	// Shading decides whether faces are lit per pixel, per vertex or with
+	// the normal of the face. Shaders are free to ignore it.
+	Shading tdraw.Shading
+	// Alpha decides what the alpha of the model's pixels is used for.
+	// Blended models in a scene are drawn after the rest of the scene, along
+	// with the faces of any model whose material can be seen through.
+	Alpha tdraw.AlphaMode
+	// AlphaCutoff is the alpha from 0 to 1 below which tdraw.AlphaTest
+	// skips pixels. Zero uses tdraw.DefaultAlphaCutoff.
+	AlphaCutoff float64
+}
+
+// renderOverrides holds the options that were set one at a time with a
+// model's setters, such as SetCullMode. Only the options that were set are
+// kept, so the rest keep following the model's RenderOptions or
+// DefaultRenderOptions when those change.
+type renderOverrides struct {
+	cull          *tdraw.CullMode
+	interpolation *tdraw.Interpolation
+	shading       *tdraw.Shading
+	alpha         *tdraw.AlphaMode
+}
+
+// apply returns the options with the overridden ones replaced.
+func (r *renderOverrides) apply(o RenderOptions) RenderOptions {
+	if r.cull != nil {
+		o.Cull = *r.cull
+	}
+	if r.interpolation != nil {
+		o.Interpolation = *r.interpolation
+	}
+	if r.shading != nil {
+		o.Shading = *r.shading
+	}
+	if r.alpha != nil {
+		o.Alpha = *r.alpha
+	}
+	return o
+}
+
+// workers returns the number of workers to draw with.
+func (o RenderOptions) workers() int {
+	if o.Workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+// alphaCutoff returns the alpha below which pixels are skipped.
+func (o RenderOptions) alphaCutoff() float64 {
+	if o.AlphaCutoff <= 0 {
+		return tdraw.DefaultAlphaCutoff
+	}
+	return o.AlphaCutoff
+}
+
+// drawState returns the state that the model's triangles are drawn with.
+func (o RenderOptions) drawState() tdraw.DrawState {
+	return tdraw.DrawState{
+		Cull:          o.Cull,
+		Interpolation: o.Interpolation,
+		Alpha:         o.Alpha,
+		AlphaCutoff:   o.alphaCutoff(),
+	}
+}
+
+// batchSize returns the number of triangles in each batch.
+func (o RenderOptions) batchSize() int {
+	if o.BatchSize < 1 {
+		return DefaultBatchSize
+	}
+	return o.BatchSize
+}
@@ -0,0 +1,190 @@
+package view
+
+import (
+	"image/draw"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/oakmound/oak/render"
+)
+
+// Scene holds many models that are seen through one camera. Unlike drawing
+// each model on its own, the models of a scene are drawn into the same color
+// and depth buffer, so they hide each other properly when they overlap.
+// This scene is a render.Renderable.
+type Scene struct {
+	// a render.Sprite has a position and a buffer of image data which
+	// it uses to draw to the screen at that position.
+	*render.Sprite
+
+	models []*Model
+	nodes  []*Node
+	lights []*tdraw.Light
+	camera *Camera
+
+	// frame is the frame buffer every model is drawn into, which is kept
+	// between frames.
+	frame *tdraw.FrameBuffer
+}
+
+// NewScene creates an empty scene with an assigned width and height that is
+// seen through the given camera.
+func NewScene(w, h int, camera *Camera) *Scene {
+	frame := tdraw.NewFrameBuffer(w, h)
+
+	return &Scene{
+		Sprite: render.NewSprite(0, 0, frame.Color),
+		camera: camera,
+		frame:  frame,
+	}
+}
+
+// Add adds models to the scene. The models are drawn with the scene's camera
+// instead of their own, and their own sprites aren't used.
+func (s *Scene) Add(models ...*Model) {
+	s.models = append(s.models, models...)
+}
+
+// Remove removes a model from the scene, if it's in it.
+func (s *Scene) Remove(m *Model) {
+	for i, mod := range s.models {
+		if mod == m {
+			s.models = append(s.models[:i], s.models[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddNode adds nodes to the scene. Every model attached to the nodes or any
+// of their children is drawn with the scene, including ones attached after
+// the node was added.
+func (s *Scene) AddNode(nodes ...*Node) {
+	s.nodes = append(s.nodes, nodes...)
+}
+
+// RemoveNode removes a node, and the models attached below it, from the
+// scene.
+func (s *Scene) RemoveNode(n *Node) {
+	for i, node := range s.nodes {
+		if node == n {
+			s.nodes = append(s.nodes[:i], s.nodes[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetNodes returns the nodes that were added to the scene.
+func (s *Scene) GetNodes() []*Node {
+	return s.nodes
+}
+
+// GetModels returns the models that were added to the scene, not including
+// the ones attached to its nodes.
+func (s *Scene) GetModels() []*Model {
+	return s.models
+}
+
+// AddLight adds lights in world space to the scene, which light every model
+// in it. The lights can be changed at any time, such as moving a torch, and
+// the changes show up the next time the scene is drawn. A scene without
+// lights is lit by a white light shining from the camera.
+func (s *Scene) AddLight(lights ...*tdraw.Light) {
+	s.lights = append(s.lights, lights...)
+}
+
+// RemoveLight removes a light from the scene.
+func (s *Scene) RemoveLight(l *tdraw.Light) {
+	for i, light := range s.lights {
+		if light == l {
+			s.lights = append(s.lights[:i], s.lights[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetLights returns the lights in the scene.
+func (s *Scene) GetLights() []*tdraw.Light {
+	return s.lights
+}
+
+// GetCamera returns the camera the scene is seen through.
+func (s *Scene) GetCamera() *Camera {
+	return s.camera
+}
+
+// SetCamera sets the camera the scene is seen through.
+func (s *Scene) SetCamera(camera *Camera) {
+	s.camera = camera
+}
+
+// GetFrameBuffer returns the frame buffer that the scene is drawn into.
+func (s *Scene) GetFrameBuffer() *tdraw.FrameBuffer {
+	return s.frame
+}
+
+// Render draws every model in the scene into the frame buffer without
+// clearing it first. The models are lit by the scene's lights instead of
+// their own. Models that are in the scene more than once, such as a model
+// that was added to the scene and attached to one of its nodes, are only
+// drawn once.
+//
+// Blended faces, which are every face of a model drawn with
+// tdraw.AlphaBlend and the faces with a material that can be seen through,
+// are drawn after all of the opaque ones. The blended faces of every model
+// are sorted together from back to front so they're seen through each other
+// as well as through the opaque models.
+func (s *Scene) Render(fb *tdraw.FrameBuffer) {
+	var (
+		w, h    = fb.Bounds().Dx(), fb.Bounds().Dy()
+		drawn   = make(map[*Model]bool)
+		blended []tdraw.Primitive
+		workers int
+	)
+
+	render := func(m *Model) {
+		if drawn[m] {
+			return
+		}
+		drawn[m] = true
+
+		opts := m.GetRenderOptions()
+		opaque, b := m.primitives(w, h, s.camera, s.lights)
+		tdraw.DrawTiled(fb, opaque, opts.workers())
+
+		blended = append(blended, b...)
+		if len(b) > 0 && opts.workers() > workers {
+			workers = opts.workers()
+		}
+	}
+
+	for _, m := range s.models {
+		render(m)
+	}
+
+	for _, n := range s.nodes {
+		n.Walk(func(node *Node) {
+			for _, m := range node.models {
+				render(m)
+			}
+		})
+	}
+
+	if len(blended) > 0 {
+		tdraw.SortBackToFront(blended)
+		tdraw.DrawTiled(fb, blended, workers)
+	}
+}
+
+// Draw calls DrawOffset at 0 offset.
+func (s *Scene) Draw(buff draw.Image) {
+	s.DrawOffset(buff, 0, 0)
+}
+
+// DrawOffset will render every model in the scene and then offset the scene
+// on the window by the provided x and y offsets.
+func (s *Scene) DrawOffset(buff draw.Image, xOff, yOff float64) {
+	s.frame.Clear()
+	s.Render(s.frame)
+
+	// Let oak render the buffer onto the window.
+	s.Sprite.DrawOffset(buff, xOff, yOff)
+}
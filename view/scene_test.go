@@ -0,0 +1,75 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// testQuad returns an .obj file with a square at the given depth that faces
+// the camera of TestSceneTranslucentMaterials, drawn with the material.
+func testQuad(z, size float64, material string) string {
+	var obj strings.Builder
+	obj.WriteString("mtllib glass.mtl\nvn 0 0 -1\n")
+	for _, v := range []mgl64.Vec2{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		fmt.Fprintf(&obj, "v %g %g %g\n", v.X()*size, v.Y()*size, z)
+	}
+	if material != "" {
+		fmt.Fprintf(&obj, "usemtl %s\n", material)
+	}
+	obj.WriteString("f 1//1 2//1 3//1 4//1\n")
+
+	return obj.String()
+}
+
+func TestSceneTranslucentMaterials(t *testing.T) {
+	const w, h = 32, 32
+
+	camera := NewCamera(mgl64.Vec3{0, 0, -3}, mgl64.DegToRad(90), 1)
+	l := &ObjLoader{Open: memoryOpen(map[string]string{
+		"glass.mtl": "newmtl glass\nKd 0 0 1\nd 0.5\n",
+	})}
+	load := func(obj string) *Model {
+		t.Helper()
+		m, err := l.LoadReader(strings.NewReader(obj), "quad.obj", nil, w, h, camera)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	// The glass is in front of a white wall. Neither model is drawn with
+	// tdraw.AlphaBlend, but the glass's material can be seen through.
+	glass := load(testQuad(-1, 0.5, "glass"))
+	wall := load(testQuad(0, 2, ""))
+
+	render := func(s *Scene) []byte {
+		fb := tdraw.NewFrameBuffer(w, h)
+		s.Render(fb)
+		return fb.Color.Pix
+	}
+
+	// The glass is added first, so it would hide the wall if it were drawn
+	// along with the opaque models.
+	scene := NewScene(w, h, camera)
+	scene.Add(glass, wall)
+	want := render(scene)
+
+	center := (h/2*w + w/2) * 4
+	if c := want[center : center+4]; c[0] == 0 || c[2] <= c[0] {
+		t.Errorf("got %v in the middle of the glass, want the wall tinted blue", c)
+	}
+
+	// Models that are in the scene and on one of its nodes are only drawn
+	// once, so the glass isn't blended twice.
+	node := NewNode("glass")
+	node.Attach(glass)
+	scene.AddNode(node)
+	if got := render(scene); !bytes.Equal(got, want) {
+		t.Errorf("the glass was drawn differently when it's on a node as well")
+	}
+}
@@ -0,0 +1,238 @@
+package view
+
+import (
+	"image/color"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// Uniforms are the values that stay the same for every vertex and pixel of
+// a model while it's being drawn.
+type Uniforms struct {
+	// Model is the model being drawn. Its vertex data, read with methods
+	// such as GetVertexPosition, is indexed by the index given to the vertex
+	// stage, and its faces, read with GetFaceMaterial, by Fragment.Face.
+	Model *Model
+	// Camera is the camera the model is seen from.
+	Camera *Camera
+
+	// View moves points from world space into eye space, where the camera
+	// is at the origin looking down -z.
+	View mgl64.Mat4
+	// ModelView moves vertices from model space into eye space, and
+	// Projection moves them from eye space into clip space.
+	ModelView  mgl64.Mat4
+	Projection mgl64.Mat4
+	// NormalMatrix moves normals from model space into eye space.
+	NormalMatrix mgl64.Mat3
+
+	// Lights are the lights in eye space. It's empty when neither the model
+	// nor its scene have any lights, in which case tdraw.Headlight is
+	// usually used.
+	Lights []tdraw.Light
+
+	// Shading is how the model's options say its faces should be lit.
+	Shading tdraw.Shading
+}
+
+// Shader decides how a model is drawn. It's bound to the model's uniforms
+// every time the model is drawn, giving the tdraw.Shader that runs for each of
+// its vertices and pixels.
+//
+// Custom effects such as hit flashes or team colors can bind a
+// StandardShader and change the colors it returns.
+type Shader interface {
+	Bind(u *Uniforms) tdraw.Shader
+}
+
+// DefaultShader is the shader of models that haven't been given one with
+// SetShader.
+var DefaultShader Shader = &StandardShader{}
+
+// StandardShader draws models the way they're described by their files. Faces
+// are colored by their material's diffuse texture or vertex colors tinted by
+// the material's diffuse color, or by the diffuse color alone when there are
+// neither. Faces without a material are colored by the model's texture,
+// vertex colors or flat color. Every face is lit by the lights with the
+// Blinn-Phong lighting model of its material, refer to Material.Shade, and
+// faces without a material are lit with the model's base material.
+//
+// Faces are lit per pixel, per vertex or with the normal of the face,
+// depending on Uniforms.Shading.
+type StandardShader struct{}
+
+// Bind returns the standard shader bound to the uniforms.
+func (s *StandardShader) Bind(u *Uniforms) tdraw.Shader {
+	m := u.Model
+
+	p := &standardProgram{
+		u:      u,
+		lights: u.Lights,
+		faces:  make([]*standardFace, len(m.faceMaterials)),
+		// The camera is usually at the origin of eye space, but it's moved
+		// there with the view matrix in case the matrix was made some other
+		// way.
+		viewer: u.View.Mul4x1(u.Camera.GetPosition().Vec4(1)).Vec3(),
+	}
+
+	if len(p.lights) == 0 {
+		p.lights = []tdraw.Light{tdraw.Headlight}
+	}
+
+	// Faces without a material are drawn with the model's texture, vertex
+	// colors or flat color. The flat color doesn't tint the texture or the
+	// vertex colors.
+	base := &standardFace{
+		material: m.GetBaseMaterial(),
+		surface: tdraw.Surface{
+			Texture:      m.texture.RGBA(),
+			VertexColors: m.outColors != nil,
+			Color:        vecToRGBA(m.color, 1),
+		},
+	}
+	if base.surface.Texture != nil || base.surface.VertexColors {
+		base.surface.Color = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	}
+
+	// Faces with a material are drawn with its diffuse texture or the
+	// model's vertex colors, tinted and faded by its diffuse color and
+	// dissolve. The model's texture is only for faces without a material.
+	faces := make(map[*Material]*standardFace)
+	for i, mat := range m.faceMaterials {
+		if mat == nil {
+			p.faces[i] = base
+			continue
+		}
+		if faces[mat] == nil {
+			faces[mat] = &standardFace{
+				material: mat,
+				surface: tdraw.Surface{
+					Texture:      mat.DiffuseMap.RGBA(),
+					VertexColors: base.surface.VertexColors,
+					Color:        premultiply(mat.Diffuse, mat.Dissolve),
+				},
+			}
+		}
+		p.faces[i] = faces[mat]
+	}
+
+	return p
+}
+
+// standardProgram is a StandardShader bound to a model's uniforms.
+type standardProgram struct {
+	u *Uniforms
+	// faces holds how every face is colored and lit.
+	faces []*standardFace
+	// lights are the lights in eye space, which are never empty.
+	lights []tdraw.Light
+	// viewer is the camera's position in eye space.
+	viewer mgl64.Vec3
+}
+
+// standardFace is the surface and material of a face drawn by a
+// StandardShader, which are shared by every face with the same material.
+type standardFace struct {
+	surface  tdraw.Surface
+	material *Material
+}
+
+func (p *standardProgram) Vertex(i int) tdraw.Vertex {
+	m := p.u.Model
+
+	// Clip space vertices with their eye space positions and normals,
+	// texture coordinates and colors.
+	eye := p.u.ModelView.Mul4x1(m.outVertices[i].Vec4(1))
+	v := tdraw.Vertex{
+		Position: p.u.Projection.Mul4x1(eye),
+		Eye:      eye.Vec3(),
+		UV:       m.outUVs[i],
+	}
+	if m.outColors != nil {
+		v.Color = m.outColors[i]
+	}
+
+	switch p.u.Shading {
+	case tdraw.FlatShading:
+		// Every vertex of the face gets the face's normal, so it's the
+		// same all the way across.
+		v.Normal = unit(p.u.NormalMatrix.Mul3x1(p.faceNormal(i / 3)))
+	case tdraw.GouraudShading:
+		// The light is worked out here and blended across the face, rather
+		// than for every pixel.
+		v.Normal = unit(p.u.NormalMatrix.Mul3x1(m.outNormals[i]))
+		v.Diffuse, v.Specular = p.faces[i/3].material.Reflect(p.lights, v.Eye, v.Normal, p.viewer)
+	default:
+		v.Normal = unit(p.u.NormalMatrix.Mul3x1(m.outNormals[i]))
+	}
+
+	return v
+}
+
+func (p *standardProgram) Fragment(f *tdraw.Fragment) (color.RGBA, bool) {
+	face := p.faces[f.Face]
+	// The surface's colors have their alpha multiplied in. It's taken out
+	// while the face is lit and multiplied back in afterwards, so the light
+	// that the face reflects and gives off fades along with it.
+	base, alpha := rgbaToVec(face.surface.Sample(f.UV, f.Color))
+	if alpha > 0 {
+		base = base.Mul(1 / alpha)
+	}
+
+	var rgb mgl64.Vec3
+	if p.u.Shading == tdraw.GouraudShading {
+		rgb = face.material.Combine(base, f.Diffuse, f.Specular)
+	} else {
+		rgb = face.material.Shade(base, p.lights, f.Eye, unit(f.Normal), p.viewer)
+	}
+
+	return premultiply(rgb, alpha), true
+}
+
+// faceNormal returns the normal of a face in model space, which points the
+// same way as the face's vertex normals.
+func (p *standardProgram) faceNormal(face int) mgl64.Vec3 {
+	var (
+		vs  = p.u.Model.outVertices[face*3 : face*3+3]
+		ns  = p.u.Model.outNormals[face*3 : face*3+3]
+		nrm = vs[1].Sub(vs[0]).Cross(vs[2].Sub(vs[0]))
+	)
+
+	// The winding of the face might not match its normals, so the normal is
+	// flipped when it disagrees with them.
+	if nrm.Dot(ns[0].Add(ns[1]).Add(ns[2])) < 0 {
+		nrm = nrm.Mul(-1)
+	}
+
+	return nrm
+}
+
+// unit returns v scaled to a length of one, or v itself when it has no
+// length.
+func unit(v mgl64.Vec3) mgl64.Vec3 {
+	if l := v.Len(); l > 0 {
+		return v.Mul(1 / l)
+	}
+	return v
+}
+
+// opacityShader fades the pixels of the shader it wraps by the model's
+// opacity.
+type opacityShader struct {
+	tdraw.Shader
+	opacity float64
+}
+
+func (s *opacityShader) Fragment(f *tdraw.Fragment) (color.RGBA, bool) {
+	c, ok := s.Shader.Fragment(f)
+
+	// The red, green and blue have the alpha multiplied in, so they're
+	// faded along with it.
+	return color.RGBA{
+		R: uint8(float64(c.R) * s.opacity),
+		G: uint8(float64(c.G) * s.opacity),
+		B: uint8(float64(c.B) * s.opacity),
+		A: uint8(float64(c.A) * s.opacity),
+	}, ok
+}
@@ -0,0 +1,183 @@
+package view
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/damienfamed75/pine/tdraw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestStandardShaderFadesLight(t *testing.T) {
+	const w, h = 32, 32
+
+	// A shiny, glowing material that can be seen through, whose highlights
+	// and glow must fade along with it.
+	l := &ObjLoader{Open: memoryOpen(map[string]string{
+		"glass.mtl": "newmtl glass\nKd 0 0 1\nKs 1 1 1\nNs 1\nKe 1 0 0\nd 0.5\n",
+	})}
+	camera := NewCamera(mgl64.Vec3{0, 0, -3}, mgl64.DegToRad(90), 1)
+	m, err := l.LoadReader(strings.NewReader(testQuad(-1, 0.5, "glass")), "quad.obj", nil, w, h, camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, shading := range []tdraw.Shading{tdraw.PhongShading, tdraw.GouraudShading, tdraw.FlatShading} {
+		t.Run(shading.String(), func(t *testing.T) {
+			m.SetShading(shading)
+			img, _ := RenderImage(m, camera, w, h)
+
+			var drawn int
+			for i := 0; i < len(img.Pix); i += 4 {
+				c := img.Pix[i : i+4]
+				if c[3] == 0 {
+					continue
+				}
+				drawn++
+
+				// The colors have their alpha multiplied in, so none of them
+				// can be larger than it.
+				if c[0] > c[3] || c[1] > c[3] || c[2] > c[3] {
+					t.Fatalf("got color %v, which is brighter than its alpha", c)
+				}
+			}
+			if drawn == 0 {
+				t.Fatal("nothing was drawn")
+			}
+		})
+	}
+}
+
+// holeShader is a custom shader that cuts out the faces with the material
+// named hole, and shades the rest from black at the bottom of the model to
+// white at the top.
+type holeShader struct {
+	hole string
+}
+
+func (s *holeShader) Bind(u *Uniforms) tdraw.Shader {
+	return &holeProgram{u: u, hole: s.hole}
+}
+
+type holeProgram struct {
+	u    *Uniforms
+	hole string
+}
+
+func (p *holeProgram) Vertex(i int) tdraw.Vertex {
+	pos := p.u.Model.GetVertexPosition(i)
+	height := (pos.Y() + 1) / 2
+
+	return tdraw.Vertex{
+		Position: p.u.Projection.Mul4(p.u.ModelView).Mul4x1(pos.Vec4(1)),
+		Color:    mgl64.Vec3{height, height, height},
+	}
+}
+
+func (p *holeProgram) Fragment(f *tdraw.Fragment) (color.RGBA, bool) {
+	if mat := p.u.Model.GetFaceMaterial(f.Face); mat != nil && mat.Name == p.hole {
+		return color.RGBA{}, false
+	}
+	return vecToRGBA(f.Color, 1), true
+}
+
+func TestCustomShader(t *testing.T) {
+	const w, h = 32, 16
+
+	// The quad on the left is cut out, and the quad on the right has a
+	// material that the shader ignores.
+	l := &ObjLoader{Open: memoryOpen(map[string]string{
+		"lib.mtl": "newmtl hole\nnewmtl green\nKd 0 1 0\n",
+	})}
+	const obj = `mtllib lib.mtl
+v -2 -1 0
+v 0 -1 0
+v 0 1 0
+v -2 1 0
+v 2 -1 0
+v 2 1 0
+usemtl hole
+f 1 2 3 4
+usemtl green
+f 2 5 6 3
+`
+	camera := NewCamera(mgl64.Vec3{0, 0, -2}, mgl64.DegToRad(90), float64(w)/h)
+	m, err := l.LoadReader(strings.NewReader(obj), "quads.obj", nil, w, h, camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetShader(&holeShader{hole: "hole"})
+
+	img, _ := RenderImage(m, camera, w, h)
+
+	// The camera looks down +z, so the left quad is on the right of the
+	// image.
+	if got := img.RGBAAt(w/2+4, h/2); got.A != 0 {
+		t.Errorf("got color %v where the faces were cut out", got)
+	}
+
+	top, bottom := img.RGBAAt(w/2-4, h/2-3), img.RGBAAt(w/2-4, h/2+3)
+	if top.A != 0xFF || bottom.A != 0xFF {
+		t.Fatalf("got colors %v and %v, want the quad drawn", top, bottom)
+	}
+	if top.R != top.G || top.G != top.B {
+		t.Errorf("got color %v, want a shade of gray", top)
+	}
+	if top.R <= bottom.R {
+		t.Errorf("got %v at the top and %v at the bottom, want it brighter at the top", top, bottom)
+	}
+}
+
+func TestStandardShaderShading(t *testing.T) {
+	const w, h = 32, 32
+
+	// The triangle's vertex normals lean out from its center like the
+	// surface of a ball, while its face points straight at the camera.
+	const obj = `v -1 -1 0
+v 1 -1 0
+v 0 1 0
+vn -0.5 -0.5 -1
+vn 0.5 -0.5 -1
+vn 0 0.5 -1
+f 1//1 2//2 3//3
+`
+	camera := NewCamera(mgl64.Vec3{0, 0, -2}, mgl64.DegToRad(90), 1)
+	m, err := LoadObjReader(strings.NewReader(obj), "tri.obj", nil, w, h, camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		shading tdraw.Shading
+		// wantUniform is whether every pixel of the face is the same color.
+		wantUniform bool
+	}{
+		{tdraw.PhongShading, false},
+		{tdraw.GouraudShading, false},
+		{tdraw.FlatShading, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shading.String(), func(t *testing.T) {
+			m.SetShading(tt.shading)
+			img, _ := RenderImage(m, camera, w, h)
+
+			colors := make(map[color.RGBA]int)
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					if c := img.RGBAAt(x, y); c.A != 0 {
+						colors[c]++
+					}
+				}
+			}
+			if len(colors) == 0 {
+				t.Fatal("nothing was drawn")
+			}
+
+			if uniform := len(colors) == 1; uniform != tt.wantUniform {
+				t.Errorf("got %d colors across the face, want uniform: %t", len(colors), tt.wantUniform)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package view
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// stlHeaderSize is the size of a binary .stl file's header and triangle
+// count, and stlTriangleSize is the size of every triangle after it.
+const (
+	stlHeaderSize   = 84
+	stlTriangleSize = 50
+)
+
+// STLLoader loads ASCII and binary .stl files.
+// The zero value is ready to use.
+type STLLoader struct {
+	// Open opens the model's file. When Open is nil files are opened from
+	// the OS.
+	Open OpenFunc
+}
+
+// LoadSTL loads an ASCII or binary .stl file into memory with an STLLoader.
+func LoadSTL(file string, w, h int, camera *Camera) (*Model, error) {
+	return new(STLLoader).Load(file, w, h, camera)
+}
+
+// LoadSTLReader reads an ASCII or binary .stl file from r with an STLLoader.
+// Refer to STLLoader.LoadReader for more information.
+func LoadSTLReader(r io.Reader, name string, w, h int, camera *Camera) (*Model, error) {
+	return new(STLLoader).LoadReader(r, name, w, h, camera)
+}
+
+// Load loads an ASCII or binary .stl file into memory. STL files only
+// describe the shape of a model, so the model doesn't have a texture and is
+// drawn with its flat color instead, refer to Model.SetColor.
+//
+// Every vertex uses the normal of its facet. Facets with a missing normal get
+// one calculated from their vertices.
+func (l *STLLoader) Load(file string, w, h int, camera *Camera) (*Model, error) {
+	f, err := l.Open.open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return l.LoadReader(f, file, w, h, camera)
+}
+
+// LoadReader reads an ASCII or binary .stl file from r, the same way Load
+// loads a file. name is used in errors.
+func (l *STLLoader) LoadReader(r io.Reader, name string, w, h int, camera *Camera) (*Model, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	mod := newModel(w, h, camera)
+
+	// ASCII files start with "solid", but so do the headers of some binary
+	// files, so a file is only binary when its size matches its triangle
+	// count.
+	if len(data) >= stlHeaderSize {
+		count := int(binary.LittleEndian.Uint32(data[80:]))
+		size := stlHeaderSize + count*stlTriangleSize
+		if len(data) == size {
+			readBinarySTL(mod, data[stlHeaderSize:], count)
+			return mod, nil
+		}
+		// Text never has zero bytes in it, while the headers and floats of
+		// binary files almost always do, so this is a binary file that was
+		// cut short or has junk after it.
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil, fmt.Errorf("%s: binary stl file is %d bytes, want %d for a triangle count of %d",
+				name, len(data), size, count)
+		}
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return nil, fmt.Errorf("%s: not an ascii or binary stl file", name)
+	}
+	if err := readASCIISTL(mod, bytes.NewReader(data), name); err != nil {
+		return nil, err
+	}
+
+	return mod, nil
+}
+
+// readBinarySTL adds count triangles from the body of a binary .stl file.
+func readBinarySTL(mod *Model, data []byte, count int) {
+	// readVec reads three little endian floats.
+	readVec := func(b []byte) mgl64.Vec3 {
+		return mgl64.Vec3{
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(b[0:]))),
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(b[4:]))),
+			float64(math.Float32frombits(binary.LittleEndian.Uint32(b[8:]))),
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		tri := data[i*stlTriangleSize:]
+		// The two byte attribute after the vertices is ignored.
+		addFacet(mod, readVec(tri[0:]), [3]mgl64.Vec3{
+			readVec(tri[12:]), readVec(tri[24:]), readVec(tri[36:]),
+		})
+	}
+}
+
+// readASCIISTL adds every facet of an ASCII .stl file.
+//
+// solid - starts a solid
+// facet normal - starts a facet with the given normal
+// outer loop - starts the vertices of a facet
+// vertex - a vertex of the facet
+// endloop, endfacet, endsolid - end their section
+func readASCIISTL(mod *Model, r io.Reader, name string) error {
+	var (
+		normal   mgl64.Vec3
+		vertices []mgl64.Vec3
+		lineNum  int
+		// ended is whether the last solid was ended with endsolid, which is
+		// how a file that was cut short is noticed.
+		ended bool
+	)
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		lineNum++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// problem describes what is wrong with the current line.
+		problem := func(err error) error {
+			return &ParseError{
+				File:      name,
+				Line:      lineNum,
+				Directive: fields[0],
+				Reason:    err.Error(),
+				Err:       err,
+			}
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "facet":
+			if len(fields) < 2 || fields[1] != "normal" {
+				return problem(fmt.Errorf("expected facet normal"))
+			}
+			normal, err = parseVec(fields[2:], 3, 3)
+			vertices = vertices[:0]
+		case "vertex":
+			var v mgl64.Vec3
+			v, err = parseVec(fields[1:], 3, 3)
+			vertices = append(vertices, v)
+		case "endfacet":
+			if len(vertices) != 3 {
+				return problem(fmt.Errorf("expected 3 vertices, got %d", len(vertices)))
+			}
+			addFacet(mod, normal, [3]mgl64.Vec3{vertices[0], vertices[1], vertices[2]})
+		case "solid":
+			ended = false
+		case "endsolid":
+			ended = true
+		case "outer", "endloop":
+			// These only group the vertices.
+		default:
+			err = fmt.Errorf("unknown keyword")
+		}
+
+		if err != nil {
+			return problem(err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// The line after the last one that was read couldn't be read.
+		return &ParseError{File: name, Line: lineNum + 1, Reason: err.Error(), Err: err}
+	}
+	if !ended {
+		return &ParseError{File: name, Line: lineNum + 1, Reason: "missing endsolid", Err: io.ErrUnexpectedEOF}
+	}
+
+	return nil
+}
+
+// addFacet adds a single triangle to the model, giving every vertex the
+// facet's normal. Normals that are missing get calculated from the vertices.
+func addFacet(mod *Model, normal mgl64.Vec3, vertices [3]mgl64.Vec3) {
+	if l := normal.Len(); l > 0 {
+		normal = normal.Mul(1 / l)
+	} else {
+		normal = vertices[1].Sub(vertices[0]).Cross(vertices[2].Sub(vertices[0]))
+		if l := normal.Len(); l > 0 {
+			normal = normal.Mul(1 / l)
+		}
+	}
+
+	for _, v := range vertices {
+		mod.outVertices = append(mod.outVertices, v)
+		mod.outNormals = append(mod.outNormals, normal)
+		mod.outUVs = append(mod.outUVs, mgl64.Vec3{})
+	}
+	mod.faceMaterials = append(mod.faceMaterials, nil)
+}
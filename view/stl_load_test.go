@@ -0,0 +1,198 @@
+package view
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// asciiSTL is a triangle in an ASCII .stl file.
+const asciiSTL = `solid tri
+facet normal 0 0 1
+outer loop
+vertex 0 0 0
+vertex 1 0 0
+vertex 0 1 0
+endloop
+endfacet
+endsolid tri
+`
+
+// binarySTL returns a binary .stl file with the given header and facets,
+// which are each a normal followed by three vertices. count is the number of
+// triangles written after the header, which may not match the facets.
+func binarySTL(header string, count uint32, facets ...[4]mgl64.Vec3) []byte {
+	var buf bytes.Buffer
+
+	h := make([]byte, 80)
+	copy(h, header)
+	buf.Write(h)
+	binary.Write(&buf, binary.LittleEndian, count)
+
+	for _, f := range facets {
+		for _, v := range f {
+			binary.Write(&buf, binary.LittleEndian, [3]float32{float32(v[0]), float32(v[1]), float32(v[2])})
+		}
+		// The attribute byte count.
+		binary.Write(&buf, binary.LittleEndian, uint16(0))
+	}
+
+	return buf.Bytes()
+}
+
+func TestLoadSTL(t *testing.T) {
+	var (
+		tri  = [3]mgl64.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+		up   = mgl64.Vec3{0, 0, 1}
+		down = mgl64.Vec3{0, 0, -1}
+	)
+
+	tests := []struct {
+		name        string
+		file        []byte
+		wantNormals []mgl64.Vec3
+	}{
+		{
+			name:        "ascii",
+			file:        []byte(asciiSTL),
+			wantNormals: []mgl64.Vec3{up, up, up},
+		},
+		{
+			// A missing normal is worked out from the vertices.
+			name:        "ascii without a normal",
+			file:        []byte(strings.Replace(asciiSTL, "normal 0 0 1", "normal 0 0 0", 1)),
+			wantNormals: []mgl64.Vec3{up, up, up},
+		},
+		{
+			name:        "binary",
+			file:        binarySTL("binary tri", 1, [4]mgl64.Vec3{down, tri[0], tri[1], tri[2]}),
+			wantNormals: []mgl64.Vec3{down, down, down},
+		},
+		{
+			// Some exporters start the header of binary files with solid,
+			// which are told apart from ASCII files by their size.
+			name:        "binary with a solid header",
+			file:        binarySTL("solid tri", 1, [4]mgl64.Vec3{down, tri[0], tri[1], tri[2]}),
+			wantNormals: []mgl64.Vec3{down, down, down},
+		},
+		{
+			name:        "binary without a normal",
+			file:        binarySTL("solid tri", 1, [4]mgl64.Vec3{{}, tri[0], tri[1], tri[2]}),
+			wantNormals: []mgl64.Vec3{up, up, up},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := LoadSTLReader(bytes.NewReader(tt.file), "tri.stl", 1, 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkVecs(t, "vertices", m.outVertices, tri[:])
+			checkVecs(t, "normals", m.outNormals, tt.wantNormals)
+			if len(m.faceMaterials) != 1 {
+				t.Errorf("got %d faces, want 1", len(m.faceMaterials))
+			}
+		})
+	}
+}
+
+func TestLoadSTLMalformed(t *testing.T) {
+	facet := [4]mgl64.Vec3{{0, 0, 1}, {0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	truncated := func(b []byte) []byte { return b[:len(b)-10] }
+
+	tests := []struct {
+		name    string
+		file    []byte
+		wantErr string
+		// wantLine is the line of the *ParseError, if there should be one.
+		wantLine int
+	}{
+		{
+			name:    "empty",
+			file:    nil,
+			wantErr: "bad.stl: not an ascii or binary stl file",
+		},
+		{
+			name:    "truncated binary",
+			file:    truncated(binarySTL("binary", 2, facet, facet)),
+			wantErr: "bad.stl: binary stl file is 174 bytes, want 184 for a triangle count of 2",
+		},
+		{
+			name:    "binary with junk after it",
+			file:    append(binarySTL("binary", 1, facet), 0, 0),
+			wantErr: "bad.stl: binary stl file is 136 bytes, want 134 for a triangle count of 1",
+		},
+		{
+			name:    "truncated header",
+			file:    binarySTL("binary", 0)[:40],
+			wantErr: "bad.stl: not an ascii or binary stl file",
+		},
+		{
+			// The file isn't mistaken for an ASCII file because of its
+			// header.
+			name:    "truncated binary with a solid header",
+			file:    truncated(binarySTL("solid tri", 2, facet, facet)),
+			wantErr: "bad.stl: binary stl file is 174 bytes, want 184 for a triangle count of 2",
+		},
+		{
+			name:     "truncated ascii",
+			file:     []byte(asciiSTL[:strings.Index(asciiSTL, "endfacet")]),
+			wantErr:  "bad.stl:8: missing endsolid",
+			wantLine: 8,
+		},
+		{
+			name:     "ascii with too few vertices",
+			file:     []byte(strings.Replace(asciiSTL, "vertex 0 1 0\n", "", 1)),
+			wantErr:  "bad.stl:7: endfacet: expected 3 vertices, got 2",
+			wantLine: 7,
+		},
+		{
+			name:     "ascii with a malformed vertex",
+			file:     []byte(strings.Replace(asciiSTL, "vertex 1 0 0", "vertex 1 zero 0", 1)),
+			wantErr:  `bad.stl:5: vertex: invalid number "zero"`,
+			wantLine: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSTLReader(bytes.NewReader(tt.file), "bad.stl", 1, 1, nil)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one starting with %q", err, tt.wantErr)
+			}
+
+			var perr *ParseError
+			if errors.As(err, &perr) != (tt.wantLine > 0) {
+				t.Fatalf("got error %#v, want a *ParseError: %t", err, tt.wantLine > 0)
+			}
+			if perr != nil && perr.Line != tt.wantLine {
+				t.Errorf("got the problem on line %d, want %d", perr.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestSTLLoaderOpen(t *testing.T) {
+	l := &STLLoader{Open: memoryOpen(map[string]string{
+		"models/tri.stl": asciiSTL,
+	})}
+
+	m, err := l.Load("models/tri.stl", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.outVertices); got != 3 {
+		t.Errorf("got %d vertices, want 3", got)
+	}
+
+	if _, err := l.Load("models/missing.stl", 1, 1, nil); !os.IsNotExist(err) {
+		t.Errorf("got error %v for a missing file, want one for which os.IsNotExist is true", err)
+	}
+}
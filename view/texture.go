@@ -0,0 +1,102 @@
+package view
+
+import (
+	"image"
+	"image/draw"
+	"io"
+
+	// Image formats that can always be decoded into textures.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/disintegration/gift"
+	"github.com/oakmound/oak/render"
+)
+
+// Texture is image data that is sampled to color the faces of a model.
+// A texture isn't tied to any model, so the same texture can be shared
+// between many models and swapped out with Model.SetTexture.
+//
+// Textures can be preprocessed with any chain of gift filters, such as
+// gift.Mean or gift.GaussianBlur to soften them. Without filters the image
+// is used as it is.
+type Texture struct {
+	// source is the unfiltered image, which is kept so that the texture can
+	// be filtered again.
+	source *image.RGBA
+	// rgba is the filtered image that gets sampled while drawing.
+	rgba *image.RGBA
+}
+
+// NewTexture creates a texture from a copy of img, running the given
+// filters over it in order.
+func NewTexture(img image.Image, filters ...gift.Filter) *Texture {
+	t := &Texture{source: toRGBA(img)}
+	t.SetFilters(filters...)
+
+	return t
+}
+
+// LoadTexture loads an image file through oak's sprite loader, so the file
+// is found relative to oak's asset paths.
+func LoadTexture(dir, file string, filters ...gift.Filter) (*Texture, error) {
+	sprite, err := render.LoadSprite(dir, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTexture(sprite.GetRGBA(), filters...), nil
+}
+
+// DecodeTexture decodes an image in any of the registered image formats
+// from r. The gif, jpeg and png formats are always registered.
+func DecodeTexture(r io.Reader, filters ...gift.Filter) (*Texture, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTexture(img, filters...), nil
+}
+
+// SetFilters replaces the filters of the texture, running the new filters
+// over the original image. Calling SetFilters without any filters removes
+// all the filtering.
+func (t *Texture) SetFilters(filters ...gift.Filter) {
+	if len(filters) == 0 {
+		t.rgba = t.source
+		return
+	}
+
+	g := gift.New(filters...)
+	t.rgba = image.NewRGBA(g.Bounds(t.source.Bounds()))
+	g.Draw(t.rgba, t.source)
+}
+
+// RGBA returns the filtered image data of the texture.
+func (t *Texture) RGBA() *image.RGBA {
+	if t == nil {
+		return nil
+	}
+
+	return t.rgba
+}
+
+// Bounds returns the size of the texture.
+func (t *Texture) Bounds() image.Rectangle {
+	if t == nil {
+		return image.Rectangle{}
+	}
+
+	return t.rgba.Bounds()
+}
+
+// toRGBA copies any image into a new *image.RGBA.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgba
+}
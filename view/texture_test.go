@@ -0,0 +1,112 @@
+package view
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/gift"
+)
+
+func TestNewTexture(t *testing.T) {
+	// The image is copied and moved to the origin.
+	img := filledImage(4, 2, color.White).SubImage(image.Rect(1, 1, 3, 2))
+	tex := NewTexture(img)
+
+	if got, want := tex.Bounds(), image.Rect(0, 0, 2, 1); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+
+	tex.RGBA().Pix[0] = 0
+	if img.(*image.RGBA).Pix[0] != 0xFF {
+		t.Error("the texture shares its pixels with the image")
+	}
+}
+
+func TestNilTexture(t *testing.T) {
+	var tex *Texture
+
+	if tex.RGBA() != nil {
+		t.Errorf("got image %v, want nil", tex.RGBA())
+	}
+	if !tex.Bounds().Empty() {
+		t.Errorf("got bounds %v, want an empty rectangle", tex.Bounds())
+	}
+}
+
+func TestLoadTexture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "texture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// oak caches sprites by their file name, so the name is kept unique to
+	// this test.
+	const file = "load_texture_test.png"
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(pngFile(t, 3, 2, color.White)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tex, err := LoadTexture(dir, file, gift.Invert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tex.Bounds(), image.Rect(0, 0, 3, 2); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	if got, want := tex.RGBA().RGBAAt(0, 0), (color.RGBA{0, 0, 0, 0xFF}); got != want {
+		t.Errorf("got color %v, want the filtered color %v", got, want)
+	}
+
+	if _, err := LoadTexture(dir, "missing_texture_test.png"); err == nil {
+		t.Error("got no error for a missing file")
+	}
+}
+
+func TestDecodeTexture(t *testing.T) {
+	tex, err := DecodeTexture(strings.NewReader(pngFile(t, 2, 3, color.White)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tex.Bounds(), image.Rect(0, 0, 2, 3); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	if got, want := tex.RGBA().RGBAAt(1, 2), (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}); got != want {
+		t.Errorf("got color %v, want %v", got, want)
+	}
+
+	if _, err := DecodeTexture(strings.NewReader("not an image")); err != image.ErrFormat {
+		t.Errorf("got error %v, want %v", err, image.ErrFormat)
+	}
+}
+
+func TestTextureSetFilters(t *testing.T) {
+	red := color.RGBA{0xFF, 0, 0, 0xFF}
+	tex := NewTexture(filledImage(4, 4, red))
+
+	// Filters may change the size of the texture.
+	tex.SetFilters(gift.Resize(2, 0, gift.NearestNeighborResampling), gift.Invert())
+	if got, want := tex.Bounds(), image.Rect(0, 0, 2, 2); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	if got, want := tex.RGBA().RGBAAt(0, 0), (color.RGBA{0, 0xFF, 0xFF, 0xFF}); got != want {
+		t.Errorf("got color %v, want %v", got, want)
+	}
+
+	// Filtering again starts over from the original image rather than the
+	// filtered one.
+	tex.SetFilters(gift.Invert())
+	if got, want := tex.Bounds(), image.Rect(0, 0, 4, 4); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+
+	tex.SetFilters()
+	if got := tex.RGBA().RGBAAt(3, 3); got != red {
+		t.Errorf("got color %v, want the unfiltered color %v", got, red)
+	}
+}
@@ -0,0 +1,145 @@
+package view
+
+import (
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// triangulate splits a polygon into triangles, returning the indices of the
+// polygon's vertices that make up each triangle. The triangles keep the
+// winding order of the polygon.
+//
+// Convex polygons are split into a fan around the first vertex, while concave
+// polygons are split by clipping ears off of the polygon until only a single
+// triangle is left.
+func triangulate(polygon []mgl64.Vec3) [][3]int {
+	if len(polygon) < 3 {
+		return nil
+	}
+
+	normal := polygonNormal(polygon)
+
+	if isConvex(polygon, normal) {
+		return triangulateFan(len(polygon))
+	}
+
+	return triangulateEars(polygon, normal)
+}
+
+// triangulateFan splits a convex polygon of n vertices into triangles that
+// all share the polygon's first vertex.
+func triangulateFan(n int) [][3]int {
+	tris := make([][3]int, 0, n-2)
+	for i := 1; i < n-1; i++ {
+		tris = append(tris, [3]int{0, i, i + 1})
+	}
+
+	return tris
+}
+
+// triangulateEars splits a polygon into triangles using ear clipping.
+// An ear is a convex corner of the polygon whose triangle doesn't contain any
+// other vertex of the polygon, so it can be cut off without changing the
+// shape of the rest of the polygon.
+func triangulateEars(polygon []mgl64.Vec3, normal mgl64.Vec3) [][3]int {
+	// remaining holds the indices of the vertices that haven't been clipped.
+	remaining := make([]int, len(polygon))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	tris := make([][3]int, 0, len(polygon)-2)
+
+	for len(remaining) > 3 {
+		clipped := false
+
+		for i := range remaining {
+			prev := remaining[(i+len(remaining)-1)%len(remaining)]
+			cur := remaining[i]
+			next := remaining[(i+1)%len(remaining)]
+
+			if !isEar(polygon, remaining, prev, cur, next, normal) {
+				continue
+			}
+
+			tris = append(tris, [3]int{prev, cur, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+
+		// Degenerate polygons (self intersecting or collinear) may not have
+		// any ears left, so fan out whatever remains instead.
+		if !clipped {
+			for _, tri := range triangulateFan(len(remaining)) {
+				tris = append(tris, [3]int{
+					remaining[tri[0]], remaining[tri[1]], remaining[tri[2]],
+				})
+			}
+			return tris
+		}
+	}
+
+	return append(tris, [3]int{remaining[0], remaining[1], remaining[2]})
+}
+
+// isEar returns whether the corner at cur can be clipped off of the polygon.
+func isEar(polygon []mgl64.Vec3, remaining []int, prev, cur, next int, normal mgl64.Vec3) bool {
+	a, b, c := polygon[prev], polygon[cur], polygon[next]
+
+	// Reflex corners can never be ears.
+	if b.Sub(a).Cross(c.Sub(b)).Dot(normal) <= 0 {
+		return false
+	}
+
+	for _, i := range remaining {
+		if i == prev || i == cur || i == next {
+			continue
+		}
+		if pointInTriangle(polygon[i], a, b, c, normal) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pointInTriangle returns whether p lies inside of, or on an edge of, the
+// triangle abc when viewed along the normal.
+func pointInTriangle(p, a, b, c, normal mgl64.Vec3) bool {
+	return b.Sub(a).Cross(p.Sub(a)).Dot(normal) >= 0 &&
+		c.Sub(b).Cross(p.Sub(b)).Dot(normal) >= 0 &&
+		a.Sub(c).Cross(p.Sub(c)).Dot(normal) >= 0
+}
+
+// isConvex returns whether every corner of the polygon turns the same way
+// around the polygon's normal.
+func isConvex(polygon []mgl64.Vec3, normal mgl64.Vec3) bool {
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		c := polygon[(i+2)%len(polygon)]
+
+		if b.Sub(a).Cross(c.Sub(b)).Dot(normal) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// polygonNormal calculates the normal of a polygon using Newell's method,
+// which works for concave polygons and isn't thrown off by collinear
+// vertices. The length of the normal isn't normalized.
+func polygonNormal(polygon []mgl64.Vec3) mgl64.Vec3 {
+	var normal mgl64.Vec3
+	for i := range polygon {
+		cur := polygon[i]
+		next := polygon[(i+1)%len(polygon)]
+
+		normal[0] += (cur.Y() - next.Y()) * (cur.Z() + next.Z())
+		normal[1] += (cur.Z() - next.Z()) * (cur.X() + next.X())
+		normal[2] += (cur.X() - next.X()) * (cur.Y() + next.Y())
+	}
+
+	return normal
+}
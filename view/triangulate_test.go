@@ -0,0 +1,126 @@
+package view
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+func TestTriangulate(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon []mgl64.Vec3
+		// want is the exact triangles, which is only checked for polygons
+		// that are split into a fan.
+		want [][3]int
+	}{
+		{
+			name:    "triangle",
+			polygon: []mgl64.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			want:    [][3]int{{0, 1, 2}},
+		},
+		{
+			name:    "convex quad is a fan",
+			polygon: []mgl64.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+			want:    [][3]int{{0, 1, 2}, {0, 2, 3}},
+		},
+		{
+			name: "convex pentagon is a fan",
+			polygon: []mgl64.Vec3{
+				{0, 0, 0}, {2, 0, 0}, {3, 1, 0}, {1, 2, 0}, {-1, 1, 0},
+			},
+			want: [][3]int{{0, 1, 2}, {0, 2, 3}, {0, 3, 4}},
+		},
+		{
+			// A fan around the first vertex would cover the notch of the
+			// dart, which is outside of the polygon.
+			name:    "concave dart is ear clipped",
+			polygon: []mgl64.Vec3{{0, 0, 0}, {2, 1, 0}, {4, 0, 0}, {2, 3, 0}},
+		},
+		{
+			name: "concave L is ear clipped",
+			polygon: []mgl64.Vec3{
+				{0, 0, 0}, {2, 0, 0}, {2, 1, 0}, {1, 1, 0}, {1, 2, 0}, {0, 2, 0},
+			},
+		},
+		{
+			// The same L standing up, so the polygon isn't on the xy plane.
+			name: "concave L on the yz plane is ear clipped",
+			polygon: []mgl64.Vec3{
+				{0, 0, 0}, {0, 2, 0}, {0, 2, 1}, {0, 1, 1}, {0, 1, 2}, {0, 0, 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tris := triangulate(tt.polygon)
+
+			if len(tris) != len(tt.polygon)-2 {
+				t.Fatalf("got %d triangles, want %d", len(tris), len(tt.polygon)-2)
+			}
+			if tt.want != nil && !reflect.DeepEqual(tris, tt.want) {
+				t.Fatalf("got triangles %v, want %v", tris, tt.want)
+			}
+
+			// Every triangle must keep the winding of the polygon, and
+			// together they must cover exactly the polygon's area without
+			// overlapping.
+			normal := polygonNormal(tt.polygon)
+			var area float64
+			for _, tri := range tris {
+				a, b, c := tt.polygon[tri[0]], tt.polygon[tri[1]], tt.polygon[tri[2]]
+				n := b.Sub(a).Cross(c.Sub(a))
+				if n.Dot(normal) <= 0 {
+					t.Errorf("triangle %v is wound the wrong way", tri)
+				}
+				area += n.Len() / 2
+			}
+
+			if want := normal.Len() / 2; math.Abs(area-want) > 1e-9 {
+				t.Errorf("triangles cover an area of %v, want %v", area, want)
+			}
+		})
+	}
+}
+
+func TestTriangulateTooFewVertices(t *testing.T) {
+	if tris := triangulate([]mgl64.Vec3{{0, 0, 0}, {1, 0, 0}}); tris != nil {
+		t.Errorf("got triangles %v for a line, want none", tris)
+	}
+}
+
+func TestLoadObjTriangulatesFaces(t *testing.T) {
+	const obj = `
+v 0 0 0
+v 2 1 0
+v 4 0 0
+v 2 3 0
+f 1 2 3 4
+f 1 3 4
+`
+	m, err := LoadObjReader(strings.NewReader(obj), "dart.obj", nil, 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dart is split into two triangles, followed by the triangle.
+	if got := len(m.outVertices); got != 9 {
+		t.Fatalf("got %d vertices, want 9", got)
+	}
+	if got := len(m.faceMaterials); got != 3 {
+		t.Fatalf("got %d face materials, want 3", got)
+	}
+
+	// The notch of the dart is never covered, so every triangle of the dart
+	// uses the notch's vertex.
+	for i := 0; i < 6; i += 3 {
+		tri := m.outVertices[i : i+3]
+		if tri[0] != (mgl64.Vec3{2, 1, 0}) && tri[1] != (mgl64.Vec3{2, 1, 0}) && tri[2] != (mgl64.Vec3{2, 1, 0}) {
+			t.Errorf("triangle %v doesn't use the notch of the dart", tri)
+		}
+	}
+}